@@ -15,10 +15,13 @@ var beginCmd = &cobra.Command{
 2. Forge Your Own Quest - Customize and generate a quest with AI assistance
 3. Seek a Mystery Quest - Get a surprise AI-generated quest
 
-Each quest contains chapters with tasks, automated validation, and AI-powered hints.`,
-	Run: quest.RunBegin,
+Each quest contains chapters with tasks, automated validation, and AI-powered hints.
+Give it a --name to keep several quests in one workspace.`,
+	RunE: quest.RunBegin,
 }
 
 func init() {
 	rootCmd.AddCommand(beginCmd)
+	beginCmd.Flags().String("name", "", "Name of the new quest, to keep several in one workspace (default \"default\")")
+	beginCmd.Flags().Bool("snapshot-module", false, "Snapshot every file of the module, not only the files of each task")
 }
@@ -1,18 +1,30 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/jovanpet/quest/internal/quest"
 	"github.com/spf13/cobra"
 )
 
 var checkCmd = &cobra.Command{
-	Use:   "check",
-	Short: "Check if your current task is complete",
-	Long:  `Validates that you have completed the requirements for the current task.`,
-	Run:   quest.RunCheck,
+	Use:         "check",
+	Short:       "Check if your current task is complete",
+	Long:        `Validates that you have completed the requirements for the current task.`,
+	RunE:        quest.RunCheck,
+	Annotations: map[string]string{quest.JSONOutputAnnotation: "true"},
 }
 
 func init() {
 	rootCmd.AddCommand(checkCmd)
 	checkCmd.Flags().BoolP("annotate", "a", false, "Add inline comments to code showing check results")
+	checkCmd.Flags().String("task", "", "Check a task by number or ID without moving to it")
+	checkCmd.Flags().String("chapter", "", "Check every task in a chapter (2) or in one quest of it (2.1)")
+	checkCmd.Flags().Bool("all", false, "Re-check every completed task plus the current one and report regressions")
+	checkCmd.Flags().Bool("uncomplete", false, "With --all, mark completed tasks that now fail as not completed")
+	checkCmd.Flags().IntP("jobs", "j", 0, "Number of rules to evaluate at once (default: number of CPUs)")
+	checkCmd.Flags().Duration("timeout", 10*time.Minute, "Give up on the whole check after this long")
+	checkCmd.Flags().Bool("no-cache", false, "Re-evaluate every rule instead of reusing results for unchanged files")
+	checkCmd.Flags().StringArray("report", nil, "Also write results as junit=path or sarif=path, can be repeated")
+	checkCmd.Flags().Bool("update-goldens", false, "Rewrite golden files in .quest/goldens from the current output")
 }
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Check every task non-interactively, for CI pipelines",
+	Long: `Runs the checks of every task in the quest without prompting, without
+saving anything and without reusing cached rule results. The build fails
+when a task recorded as completed no longer passes, or when any task fails
+with --require-all.
+
+Exit codes: 0 pass, 1 fail, 2 warn, 3 no quest found, 4 internal error.`,
+	RunE: quest.RunCI,
+}
+
+func init() {
+	rootCmd.AddCommand(ciCmd)
+	ciCmd.Flags().Bool("require-all", false, "Fail when any task fails, not only completed ones")
+	ciCmd.Flags().IntP("jobs", "j", 0, "Number of rules to evaluate at once (default: number of CPUs)")
+	ciCmd.Flags().Duration("timeout", 30*time.Minute, "Give up on the whole run after this long")
+}
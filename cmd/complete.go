@@ -13,9 +13,10 @@ var completeCmd = &cobra.Command{
 
 Use this command after you've finished implementing a task and validated it with 'quest check'.
 Your progress will be saved and the next task will be displayed.`,
-	Run: quest.RunCompete,
+	RunE: quest.RunCompete,
 }
 
 func init() {
 	rootCmd.AddCommand(completeCmd)
+	completeCmd.Flags().BoolP("yes", "y", false, "Complete the quest without asking for confirmation")
 }
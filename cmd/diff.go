@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what changed in a task's files since a snapshot",
+	Long: `Shows a diff of the task's files against one of the snapshots quest keeps
+in .quest/snapshots. Snapshots are taken when 'quest next' starts a task
+(start), on every passing check (pass), before AI hints or annotations edit your
+files (ai) and before 'quest restore' overwrites them (restore).
+
+By default the current task is compared with how it started.`,
+	RunE: quest.RunDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().String("task", "", "Task number to compare (default the current task)")
+	diffCmd.Flags().String("at", quest.SnapshotStart, "Snapshot to compare with: start, pass, ai or restore")
+}
@@ -16,7 +16,8 @@ lines where you might need to consider edge cases or fix issues.
 
 Example:
   quest explain`,
-	Run: quest.RunExplain,
+	RunE:        quest.RunExplain,
+	Annotations: map[string]string{quest.JSONOutputAnnotation: "true"},
 }
 
 func init() {
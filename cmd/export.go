@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Pack your progress into a bundle to move it to another machine",
+	Long: `Writes the current quest to a gzipped tarball, progress.tar.gz unless you
+name another file: the plan, your state, the activity log, the snapshots of your
+files, goldens and any notes you keep in the quest folder. A manifest with a
+checksum of every file lets 'quest import' verify the bundle.
+
+Your code isn't included, bring it along with git or a copy of the folder.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: quest.RunExport,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
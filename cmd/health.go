@@ -11,7 +11,8 @@ var healthCmd = &cobra.Command{
 	Short: "Check the health status of the quest",
 	Long: `This command checks the health status of the quest system and reports any issues found.
 	Checks include: checking the .quest folder, loading and unloading configurations, and verifying system components.`,
-	Run: quest.RunHealthCheck,
+	RunE:        quest.RunHealthCheck,
+	Annotations: map[string]string{quest.JSONOutputAnnotation: "true"},
 }
 
 func init() {
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [number|id]",
+	Short: "List and inspect the quests you have finished",
+	Long: `Lists the quests archived by 'quest complete', most recent first. Pass a
+number from the list, or an archive id, to see the final summary and stats of
+that quest.
+
+Archives are kept in $XDG_DATA_HOME/quest/archive, ~/.local/share/quest/archive
+by default.`,
+	Args:        cobra.MaximumNArgs(1),
+	RunE:        quest.RunHistory,
+	Annotations: map[string]string{quest.JSONOutputAnnotation: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Restore progress from a bundle made by quest export",
+	Long: `Verifies a bundle made by 'quest export', its format, schema version and
+the checksum of every file, and unpacks it into this workspace as the current
+quest. It keeps the name it was exported with unless you give another with
+--name.
+
+An existing quest is never overwritten: when the workspace already has a quest
+of that name, import stops and asks you to pick another name.`,
+	Args: cobra.ExactArgs(1),
+	RunE: quest.RunImport,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().String("name", "", "Name to import the quest under")
+}
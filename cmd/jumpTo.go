@@ -12,7 +12,8 @@ var jumpToCmd = &cobra.Command{
 	Long: `Jump to a specific task index or the last completed task in your quest.
 
 You can specify the task index as an argument or use the --last-complete flag to jump to the last completed task.`,
-	Run: quest.RunJumpTo,
+	RunE:        quest.RunJumpTo,
+	Annotations: map[string]string{quest.JSONOutputAnnotation: "true"},
 }
 
 func init() {
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var listQuestsCmd = &cobra.Command{
+	Use:         "list-quests",
+	Short:       "List the quests in this workspace",
+	Long:        `Lists every quest in .quest with its journey and progress, marking the current one.`,
+	RunE:        quest.RunListQuests,
+	Annotations: map[string]string{quest.JSONOutputAnnotation: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(listQuestsCmd)
+}
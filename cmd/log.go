@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Browse the history of checks, hints and navigation",
+	Long: `Shows the activity log kept in .quest/events.jsonl: every begin, next,
+check (with its rule outcomes and duration), explain (with the hints given),
+jumpTo, complete and reset, oldest first.
+
+Filter with --type, --task and --since, and add --verbose to see the rules
+and hints of each event.`,
+	RunE:        quest.RunLog,
+	Annotations: map[string]string{quest.JSONOutputAnnotation: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+	logCmd.Flags().StringSlice("type", nil, "Only show these event types: begin, next, check, explain, jumpTo, complete, reset")
+	logCmd.Flags().String("task", "", "Only show events of this task, by number or ID")
+	logCmd.Flags().String("since", "", "Only show events after a duration ago (e.g. 24h) or a date (e.g. 2006-01-02)")
+	logCmd.Flags().IntP("limit", "n", 20, "Show at most this many of the latest events, 0 for all")
+	logCmd.Flags().BoolP("verbose", "v", false, "Show the rule outcomes and hints of each event")
+}
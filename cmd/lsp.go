@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Serve check results and hints to your editor as a language server",
+	Long: `Runs a Language Server Protocol server on stdin and stdout. Editors start it
+and show quest feedback inline without any comments being written into your
+files: failed rules, AI hints and review comments appear as diagnostics, hovering
+shows the current task, and saving a file checks the task again.
+
+Code actions run "quest.check", "quest.explain" and "quest.annotate".`,
+	RunE:        quest.RunLSP,
+	Annotations: map[string]string{quest.ProtocolOutputAnnotation: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+	lspCmd.Flags().IntP("jobs", "j", 0, "Number of rules to evaluate at once (default: number of CPUs)")
+	lspCmd.Flags().Duration("timeout", 10*time.Minute, "Give up on a single check after this long")
+	lspCmd.Flags().Bool("no-cache", false, "Re-evaluate every rule instead of reusing results for unchanged files")
+}
@@ -6,12 +6,14 @@ import (
 )
 
 var nextCmd = &cobra.Command{
-	Use:   "next",
-	Short: "Move to the next task in your quest",
-	Long:  `Advances to the next task and displays what you need to work on.`,
-	Run:   quest.RunNext,
+	Use:         "next",
+	Short:       "Move to the next task in your quest",
+	Long:        `Advances to the next task and displays what you need to work on.`,
+	RunE:        quest.RunNext,
+	Annotations: map[string]string{quest.JSONOutputAnnotation: "true"},
 }
 
 func init() {
 	rootCmd.AddCommand(nextCmd)
+	nextCmd.Flags().BoolP("force", "f", false, "Move on even if the last check didn't pass, without asking")
 }
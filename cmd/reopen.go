@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var reopenCmd = &cobra.Command{
+	Use:   "reopen <number|id>",
+	Short: "Restore a finished quest from the archive",
+	Long: `Moves an archived quest back into this workspace and makes it the current
+quest, so you can pick it up where you left it. It keeps the name it had unless
+you give another with --name.`,
+	Args: cobra.ExactArgs(1),
+	RunE: quest.RunReopen,
+}
+
+func init() {
+	rootCmd.AddCommand(reopenCmd)
+	reopenCmd.Flags().String("name", "", "Name to restore the quest under")
+}
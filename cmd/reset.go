@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var resetCmd = &cobra.Command{
+	Use:   "reset [task-number]",
+	Short: "Redo a task from scratch",
+	Long: `Puts the files a task starts with back the way they were when the task
+started, or back to their stub when quest has no snapshot of that, and forgets
+the task's check results: it is no longer completed and its hint level starts
+over. The current task is reset unless you give a task number.
+
+quest shows everything that will be overwritten and asks first. Your files are
+snapshotted before, so 'quest restore --at restore' brings them back.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: quest.RunReset,
+}
+
+func init() {
+	rootCmd.AddCommand(resetCmd)
+	resetCmd.Flags().BoolP("yes", "y", false, "Reset without asking for confirmation")
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Roll a task's files back to a snapshot",
+	Long: `Puts the task's files back the way they were in one of its snapshots: how
+the task started, the last time its check passed, before the last AI edit or
+before the last restore. Files the snapshot doesn't know are left alone.
+
+quest lists the files it will overwrite and asks before touching them. Your
+files are snapshotted first, so 'quest restore --at restore' undoes a restore.`,
+	RunE: quest.RunRestore,
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().String("task", "", "Task number to restore (default the current task)")
+	restoreCmd.Flags().String("at", quest.SnapshotPass, "Snapshot to restore: start, pass, ai or restore")
+	restoreCmd.Flags().BoolP("yes", "y", false, "Restore without asking for confirmation")
+}
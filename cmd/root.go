@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
+	"github.com/jovanpet/quest/internal/quest"
 	"github.com/spf13/cobra"
 )
 
@@ -16,20 +19,49 @@ Choose from curated templates covering REST APIs, CLI tools, concurrency pattern
 Each quest guides you through tasks with automated validation and AI-powered hints.
 
 Perfect for beginners learning Go fundamentals or experienced developers exploring new patterns.`,
+	// Commands print their own errors, the exit code carries the outcome
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := quest.ConfigureOutput(cmd); err != nil {
+			return err
+		}
+		if err := quest.EnterQuestRoot(cmd); err != nil {
+			return err
+		}
+		if err := quest.SelectQuest(cmd); err != nil {
+			return err
+		}
+		quest.EnterWorkspace()
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// The process exits with one of the quest.Exit* codes.
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		var exitErr *quest.ExitError
+		if !errors.As(err, &exitErr) {
+			// Usage errors from cobra haven't been printed yet
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		os.Exit(quest.ExitCode(err))
 	}
 }
 
+// Root returns the quest command with all of its subcommands, for tests that
+// run commands with the flags the CLI gives them
+func Root() *cobra.Command {
+	return rootCmd
+}
+
 func init() {
+	rootCmd.PersistentFlags().String("root", "", "Directory holding the .quest folder (default: the nearest one in this or a parent directory)")
+	rootCmd.PersistentFlags().String("quest", "", "Name of the quest to use instead of the current one")
+	rootCmd.PersistentFlags().StringP("output", "o", quest.OutputText, "Output format: text or json (check, summary, next, health, jumpTo, explain, log, stats, list-quests, history)")
 	// Future: Add config file support
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.quest.yaml)")
 }
-
-
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "See where your time went and how long the rest should take",
+	Long: `Shows the time spent on each task and chapter, how many checks and hints
+each task took, the slowest tasks, and an estimate for the remaining tasks at
+your current pace.
+
+A task's time runs from when 'quest next' first showed it until its first
+passing check.`,
+	RunE:        quest.RunStats,
+	Annotations: map[string]string{quest.JSONOutputAnnotation: "true"},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+}
@@ -13,7 +13,8 @@ var summaryCmd = &cobra.Command{
 
 Shows all chapters, tasks, and your completion status across the entire quest.
 Use this to track what you've accomplished and what's left to complete.`,
-	Run: quest.RunSummary,
+	RunE:        quest.RunSummary,
+	Annotations: map[string]string{quest.JSONOutputAnnotation: "true"},
 }
 
 func init() {
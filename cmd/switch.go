@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var switchCmd = &cobra.Command{
+	Use:   "switch <name>",
+	Short: "Make another quest in this workspace the current one",
+	Long: `Makes the named quest the one every command uses, until you switch again
+or begin another quest. Use --quest <name> to run a single command on another
+quest instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: quest.RunSwitch,
+}
+
+func init() {
+	rootCmd.AddCommand(switchCmd)
+}
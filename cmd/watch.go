@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Re-check your current task whenever its files change",
+	Long: `Watches the files the current task depends on and runs the check again
+each time they change, redrawing the result in place. Press Ctrl-C to stop.`,
+	RunE: quest.RunWatch,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().Duration("interval", 500*time.Millisecond, "How often to look for changed files")
+	watchCmd.Flags().Duration("debounce", 300*time.Millisecond, "Wait for files to stop changing this long before checking")
+	watchCmd.Flags().IntP("jobs", "j", 0, "Number of rules to evaluate at once (default: number of CPUs)")
+	watchCmd.Flags().Duration("timeout", 10*time.Minute, "Give up on a single check after this long")
+	watchCmd.Flags().Bool("no-cache", false, "Re-evaluate every rule instead of reusing results for unchanged files")
+}
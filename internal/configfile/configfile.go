@@ -0,0 +1,200 @@
+// Package configfile parses JSON, YAML, TOML and .env files into plain Go
+// values (map[string]interface{}, []interface{}, string, float64, bool, nil)
+// so rules can assert on them without caring about the source format. Dates
+// and times are kept as strings.
+package configfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+	FormatEnv  Format = "env"
+)
+
+// ParseError is a syntax error with a 1-based position in the source file.
+// Column is 0 when the parser only reports the line.
+type ParseError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+func errorAt(line, column int, format string, args ...interface{}) *ParseError {
+	return &ParseError{Line: line, Column: column, Msg: fmt.Sprintf(format, args...)}
+}
+
+// DetectFormat picks the format from the file name
+func DetectFormat(path string) (Format, error) {
+	base := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.HasSuffix(base, ".json"):
+		return FormatJSON, nil
+	case strings.HasSuffix(base, ".yaml"), strings.HasSuffix(base, ".yml"):
+		return FormatYAML, nil
+	case strings.HasSuffix(base, ".toml"):
+		return FormatTOML, nil
+	case base == ".env", strings.HasPrefix(base, ".env."), strings.HasSuffix(base, ".env"):
+		return FormatEnv, nil
+	}
+	return "", fmt.Errorf("cannot tell the config format of '%s', set 'format' on the rule", path)
+}
+
+// ParseFile reads and parses path. An empty format is detected from the name.
+func ParseFile(path string, format Format) (interface{}, error) {
+	if format == "" {
+		detected, err := DetectFormat(path)
+		if err != nil {
+			return nil, err
+		}
+		format = detected
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, format)
+}
+
+// Parse parses data in the given format
+func Parse(data []byte, format Format) (interface{}, error) {
+	switch format {
+	case FormatJSON:
+		return ParseJSON(data)
+	case FormatYAML:
+		return ParseYAML(data)
+	case FormatTOML:
+		return ParseTOML(data)
+	case FormatEnv:
+		return ParseEnv(data)
+	}
+	return nil, fmt.Errorf("unsupported config format '%s'", format)
+}
+
+// plainValue turns what the YAML and TOML decoders produce into the plain
+// values JSON decodes to: string keys, []interface{} lists, float64 numbers
+// and dates as strings
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = plainValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = plainValue(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = plainValue(item)
+		}
+		return v
+	case []map[string]interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = plainValue(item)
+		}
+		return converted
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case time.Time:
+		return timeString(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}
+
+// Lookup resolves a key path like "services.web.ports[0]" inside value
+func Lookup(value interface{}, path string) (interface{}, bool, error) {
+	segments, err := splitKeyPath(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current := value
+	for _, seg := range segments {
+		if seg.isIndex {
+			list, ok := current.([]interface{})
+			if !ok || seg.index >= len(list) {
+				return nil, false, nil
+			}
+			current = list[seg.index]
+			continue
+		}
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false, nil
+		}
+		current, ok = obj[seg.key]
+		if !ok {
+			return nil, false, nil
+		}
+	}
+	return current, true, nil
+}
+
+type keySegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func splitKeyPath(path string) ([]keySegment, error) {
+	var segments []keySegment
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		var indexes []int
+		if i := strings.Index(part, "["); i >= 0 {
+			key = part[:i]
+			rest := part[i:]
+			for rest != "" {
+				end := strings.Index(rest, "]")
+				if !strings.HasPrefix(rest, "[") || end < 0 {
+					return nil, fmt.Errorf("invalid key path '%s'", path)
+				}
+				n, err := strconv.Atoi(rest[1:end])
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid index in key path '%s'", path)
+				}
+				indexes = append(indexes, n)
+				rest = rest[end+1:]
+			}
+		}
+		if key == "" && len(indexes) == 0 {
+			return nil, fmt.Errorf("invalid key path '%s'", path)
+		}
+		if key != "" {
+			segments = append(segments, keySegment{key: key})
+		}
+		for _, n := range indexes {
+			segments = append(segments, keySegment{index: n, isIndex: true})
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,287 @@
+package configfile
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		input    string
+		expected interface{}
+	}{
+		{
+			name:   "json",
+			format: FormatJSON,
+			input:  `{"port": 8080, "debug": true, "tags": ["a"]}`,
+			expected: map[string]interface{}{
+				"port": 8080.0, "debug": true, "tags": []interface{}{"a"},
+			},
+		},
+		{
+			name:   "yaml mappings and sequences",
+			format: FormatYAML,
+			input: `# compose file
+version: "3.8"
+services:
+  web:
+    image: nginx:latest   # pinned later
+    ports:
+      - "80:80"
+      - 443
+    environment:
+    - DEBUG=1
+  db:
+    image: postgres
+    healthcheck: {test: [CMD, pg_isready], retries: 5}
+`,
+			expected: map[string]interface{}{
+				"version": "3.8",
+				"services": map[string]interface{}{
+					"web": map[string]interface{}{
+						"image":       "nginx:latest",
+						"ports":       []interface{}{"80:80", 443.0},
+						"environment": []interface{}{"DEBUG=1"},
+					},
+					"db": map[string]interface{}{
+						"image": "postgres",
+						"healthcheck": map[string]interface{}{
+							"test":    []interface{}{"CMD", "pg_isready"},
+							"retries": 5.0,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:   "yaml list of mappings and block scalar",
+			format: FormatYAML,
+			input: `routes:
+  - path: /health
+    method: GET
+  - path: /todos
+    enabled: false
+script: |
+  go build
+  go test
+empty:
+`,
+			expected: map[string]interface{}{
+				"routes": []interface{}{
+					map[string]interface{}{"path": "/health", "method": "GET"},
+					map[string]interface{}{"path": "/todos", "enabled": false},
+				},
+				"script": "go build\ngo test\n",
+				"empty":  nil,
+			},
+		},
+		{
+			name:   "yaml anchors, aliases and merge keys",
+			format: FormatYAML,
+			input: `x-common: &common
+  restart: always
+  environment:
+    LOG: debug
+services:
+  web:
+    <<: *common
+    image: nginx
+  worker:
+    <<: [*common]
+    restart: "no"
+ports: &ports [80, 443]
+copy: *ports
+tagged: !!str 1
+`,
+			expected: map[string]interface{}{
+				"x-common": map[string]interface{}{
+					"restart":     "always",
+					"environment": map[string]interface{}{"LOG": "debug"},
+				},
+				"services": map[string]interface{}{
+					"web": map[string]interface{}{
+						"restart":     "always",
+						"environment": map[string]interface{}{"LOG": "debug"},
+						"image":       "nginx",
+					},
+					"worker": map[string]interface{}{
+						"restart":     "no",
+						"environment": map[string]interface{}{"LOG": "debug"},
+					},
+				},
+				"ports":  []interface{}{80.0, 443.0},
+				"copy":   []interface{}{80.0, 443.0},
+				"tagged": "1",
+			},
+		},
+		{
+			name:   "toml",
+			format: FormatTOML,
+			input: `title = "quest" # comment
+[server]
+port = 8_080
+hosts = [
+  "a",
+  "b",
+]
+
+[server.tls]
+enabled = true
+
+[[workers]]
+name = 'one'
+[[workers]]
+name = "two"
+limits = { cpu = 1.5 }
+released = 1979-05-27T07:32:00Z
+day = 1979-05-27
+`,
+			expected: map[string]interface{}{
+				"title": "quest",
+				"server": map[string]interface{}{
+					"port":  8080.0,
+					"hosts": []interface{}{"a", "b"},
+					"tls":   map[string]interface{}{"enabled": true},
+				},
+				"workers": []interface{}{
+					map[string]interface{}{"name": "one"},
+					map[string]interface{}{
+						"name": "two", "limits": map[string]interface{}{"cpu": 1.5},
+						"released": "1979-05-27T07:32:00Z", "day": "1979-05-27",
+					},
+				},
+			},
+		},
+		{
+			name:   "toml implicit tables",
+			format: FormatTOML,
+			input:  "[a.b]\nx = 1\n[a]\ny = 2\n[[w]]\n[w.s]\n[[w]]\n[w.s]\n",
+			expected: map[string]interface{}{
+				"a": map[string]interface{}{"b": map[string]interface{}{"x": 1.0}, "y": 2.0},
+				"w": []interface{}{
+					map[string]interface{}{"s": map[string]interface{}{}},
+					map[string]interface{}{"s": map[string]interface{}{}},
+				},
+			},
+		},
+		{
+			name:   "env",
+			format: FormatEnv,
+			input:  "# settings\nPORT=8080\nexport DB_URL=\"postgres://localhost\"\nNAME='quest' \nEMPTY=\nMODE=dev # inline\n",
+			expected: map[string]interface{}{
+				"PORT": "8080", "DB_URL": "postgres://localhost", "NAME": "quest", "EMPTY": "", "MODE": "dev",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := Parse([]byte(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(value, tt.expected) {
+				t.Errorf("Parse() = %#v, expected %#v", value, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+		line   int
+		column int
+	}{
+		{name: "json", format: FormatJSON, input: "{\n  \"a\": 1,\n  \"b\" 2\n}", line: 3, column: 7},
+		// yaml.v3 reports the line of a syntax error but no column
+		{name: "yaml indentation", format: FormatYAML, input: "a: 1\n   b: 2\n", line: 2, column: 0},
+		{name: "yaml tab", format: FormatYAML, input: "a:\n\tb: 2\n", line: 2, column: 0},
+		{name: "yaml flow", format: FormatYAML, input: "a: [1, 2\n", line: 1, column: 0},
+		{name: "yaml mapping in a plain value", format: FormatYAML, input: "a: 1\nb: c: d\n", line: 2, column: 0},
+		{name: "toml missing equals", format: FormatTOML, input: "a = 1\nb 2\n", line: 2, column: 3},
+		{name: "toml duplicate", format: FormatTOML, input: "a = 1\na = 2\n", line: 2, column: 7},
+		{name: "toml duplicate table", format: FormatTOML, input: "[a]\nb = 1\n\n[a]\nc = 2\n", line: 4, column: 2},
+		{name: "env", format: FormatEnv, input: "A=1\n  B\n", line: 2, column: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input), tt.format)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected ParseError, got %v", err)
+			}
+			if parseErr.Line != tt.line || parseErr.Column != tt.column {
+				t.Errorf("Expected position %d:%d, got %d:%d (%s)", tt.line, tt.column, parseErr.Line, parseErr.Column, parseErr.Msg)
+			}
+		})
+	}
+}
+
+func TestParseYAMLUnknownAlias(t *testing.T) {
+	if _, err := Parse([]byte("a: 1\nb: [1, *a]\n"), FormatYAML); err == nil {
+		t.Error("Expected an alias without an anchor to be refused")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]Format{
+		"config.yaml":         FormatYAML,
+		"docker-compose.yml":  FormatYAML,
+		"settings.json":       FormatJSON,
+		"Cargo.toml":          FormatTOML,
+		".env":                FormatEnv,
+		".env.example":        FormatEnv,
+		"deploy/prod.env":     FormatEnv,
+		"config/app.CONF.yml": FormatYAML,
+	}
+	for path, expected := range tests {
+		got, err := DetectFormat(path)
+		if err != nil || got != expected {
+			t.Errorf("DetectFormat(%q) = %q, %v, expected %q", path, got, err, expected)
+		}
+	}
+
+	if _, err := DetectFormat("README.md"); err == nil {
+		t.Error("Expected error for unknown extension")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	value := map[string]interface{}{
+		"services": map[string]interface{}{
+			"web": map[string]interface{}{"ports": []interface{}{"80:80", "443:443"}},
+		},
+	}
+
+	tests := []struct {
+		path     string
+		expected interface{}
+		found    bool
+	}{
+		{path: "services.web.ports[1]", expected: "443:443", found: true},
+		{path: "services.web.ports[2]", found: false},
+		{path: "services.db", found: false},
+		{path: "services.web.ports.x", found: false},
+	}
+
+	for _, tt := range tests {
+		got, found, err := Lookup(value, tt.path)
+		if err != nil {
+			t.Fatalf("Lookup(%q) error = %v", tt.path, err)
+		}
+		if found != tt.found || !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Lookup(%q) = %v, %v, expected %v, %v", tt.path, got, found, tt.expected, tt.found)
+		}
+	}
+
+	if _, _, err := Lookup(value, "services[x]"); err == nil {
+		t.Error("Expected error for invalid key path")
+	}
+}
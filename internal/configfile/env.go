@@ -0,0 +1,73 @@
+package configfile
+
+import (
+	"regexp"
+	"strings"
+)
+
+var envKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ParseEnv parses KEY=VALUE lines. Every value is a string.
+func ParseEnv(data []byte) (interface{}, error) {
+	result := map[string]interface{}{}
+
+	for i, raw := range strings.Split(string(data), "\n") {
+		lineNum := i + 1
+		line := strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		column := len(line) - len(strings.TrimLeft(line, " \t")) + 1
+
+		if strings.HasPrefix(trimmed, "export ") {
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "export "))
+		}
+
+		eq := strings.Index(trimmed, "=")
+		if eq < 0 {
+			return nil, errorAt(lineNum, column, "expected KEY=VALUE")
+		}
+
+		key := strings.TrimSpace(trimmed[:eq])
+		if !envKeyRegex.MatchString(key) {
+			return nil, errorAt(lineNum, column, "invalid variable name '%s'", key)
+		}
+
+		value, err := envValue(strings.TrimSpace(trimmed[eq+1:]))
+		if err != nil {
+			return nil, errorAt(lineNum, strings.Index(line, "=")+2, "%s", err.Msg)
+		}
+		result[key] = value
+	}
+
+	return result, nil
+}
+
+func envValue(raw string) (string, *ParseError) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '"':
+		end := strings.LastIndex(raw, "\"")
+		if end == 0 {
+			return "", &ParseError{Msg: "unterminated double-quoted value"}
+		}
+		replacer := strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+		return replacer.Replace(raw[1:end]), nil
+	case '\'':
+		end := strings.LastIndex(raw, "'")
+		if end == 0 {
+			return "", &ParseError{Msg: "unterminated single-quoted value"}
+		}
+		return raw[1:end], nil
+	}
+
+	// Unquoted values may carry a trailing comment
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
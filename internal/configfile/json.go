@@ -0,0 +1,38 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ParseJSON decodes JSON, reporting syntax errors with line and column
+func ParseJSON(data []byte) (interface{}, error) {
+	var value interface{}
+	err := json.Unmarshal(data, &value)
+	if err == nil {
+		return value, nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := position(data, syntaxErr.Offset)
+		return nil, errorAt(line, column, "%s", syntaxErr.Error())
+	}
+	return nil, err
+}
+
+// position converts a SyntaxError offset, which counts the offending byte,
+// into the 1-based line and column of that byte
+func position(data []byte, offset int64) (int, int) {
+	if offset > 0 {
+		offset--
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
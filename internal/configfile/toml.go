@@ -0,0 +1,37 @@
+package configfile
+
+import (
+	"errors"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// localTimeLayouts writes back TOML dates and times without an offset, which
+// the decoder marks with these zone names, the way they were written
+var localTimeLayouts = map[string]string{
+	"datetime-local": "2006-01-02T15:04:05.999999999",
+	"date-local":     "2006-01-02",
+	"time-local":     "15:04:05.999999999",
+}
+
+// timeString formats a decoded date or time as it appears in the file
+func timeString(t time.Time) string {
+	if layout, ok := localTimeLayouts[t.Location().String()]; ok {
+		return t.Format(layout)
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// ParseTOML parses a TOML document. Dates and times are kept as strings.
+func ParseTOML(data []byte) (interface{}, error) {
+	value := map[string]interface{}{}
+	if _, err := toml.Decode(string(data), &value); err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, errorAt(parseErr.Position.Line, parseErr.Position.Col, "%s", parseErr.Message)
+		}
+		return nil, err
+	}
+	return plainValue(value), nil
+}
@@ -0,0 +1,26 @@
+package configfile
+
+import (
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlErrorRegex picks the line out of a yaml.v3 syntax error, which has no
+// column
+var yamlErrorRegex = regexp.MustCompile(`^yaml: line ([0-9]+): (.*)$`)
+
+// ParseYAML parses the first document of a YAML stream, anchors, aliases,
+// merge keys and tags included, the way docker-compose reads it
+func ParseYAML(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		if m := yamlErrorRegex.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return nil, errorAt(line, 0, "%s", m[2])
+		}
+		return nil, err
+	}
+	return plainValue(value), nil
+}
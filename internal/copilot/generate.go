@@ -85,6 +85,28 @@ VALIDATION RULE TYPES:
 3. "file_contains_any" - Check file contains specific strings
    Example: {"type": "file_contains_any", "name": "Uses HTTP handler", "glob": "*.go", "any": ["http.HandleFunc", "http.Handler"]}
 
+4. "function_cases" - Call an exported function with JSON args and compare the result or error
+   "path" is the package directory, args are decoded into the function's parameter types
+   Example: {"type": "function_cases", "name": "Divide works", "path": "calc", "function": "Divide", "cases": [
+     {"name": "halves", "args": [6, 3], "expected": 2},
+     {"name": "by zero", "args": [1, 0], "expectedError": "division by zero"}
+   ]}
+
+5. "config_file" - Parse a JSON/YAML/TOML/.env file and check key paths (type, equals, matches) or a JSON Schema
+   Example: {"type": "config_file", "name": "Config has a port", "path": "config.yaml", "keys": [
+     {"key": "server.port", "type": "integer"},
+     {"key": "log_level", "matches": "^(debug|info)$"}
+   ]}
+
+6. "command" - Run a CLI and compare its stdout with a golden, after optional normalizers
+   Example: {"type": "command", "name": "Prints usage", "command": "go run .", "args": ["--help"],
+     "golden": "help.txt", "goldenText": "usage: todo [add|list]\n", "normalize": ["timestamps"]}
+
+7. "git_commits", "git_clean", "git_branch", "git_message" - Check the learner's git repository
+   git_commits counts commits since the task started ("min"), git_clean needs no uncommitted changes,
+   git_branch and git_message match the branch name or each new commit subject against "pattern"
+   Example: {"type": "git_message", "name": "Conventional commits", "pattern": "^(feat|fix|docs|test|chore): "}
+
 OUTPUT FORMAT (strict JSON):
 {
   "version": 1,
@@ -139,6 +161,7 @@ IMPORTANT RULES:
 - Make it educational but fun
 - Use proper Go project structure (cmd/, internal/, pkg/ if needed)
 - Include at least 2 validation rules per task
+- Prefer "function_cases" over "file_contains_any" whenever a task asks for a function; name its exact signature in the steps
 
 Generate the complete quest plan now as valid JSON:`,
 		spec.BuildType,
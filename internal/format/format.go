@@ -2,6 +2,8 @@ package format
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 )
@@ -47,79 +49,88 @@ const (
 	BoxStar        = "✨"
 )
 
+// out receives everything this package prints
+var out io.Writer = os.Stdout
+
+// SetOutput redirects everything this package prints, such as to
+// io.Discard when a command reports JSON instead
+func SetOutput(w io.Writer) {
+	out = w
+}
+
 // Header prints a styled header with icon
 func Header(title string) {
-	fmt.Println()
-	fmt.Printf("  %s%s%s %s%s\n", ColorPink, ColorBold, "🧭", title, ColorReset)
-	fmt.Printf("  %s%s%s\n\n", ColorDim, strings.Repeat("─", len(title)+2), ColorReset)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  %s%s%s %s%s\n", ColorPink, ColorBold, "🧭", title, ColorReset)
+	fmt.Fprintf(out, "  %s%s%s\n\n", ColorDim, strings.Repeat("─", len(title)+2), ColorReset)
 }
 
 // Success prints a success message
 func Success(msg string) {
-	fmt.Printf("  %s%s %s%s%s\n", BgGreen, ColorBold, BoxCheck, ColorReset, fmt.Sprintf(" %s%s%s", ColorGreen, msg, ColorReset))
-	fmt.Println()
+	fmt.Fprintf(out, "  %s%s %s%s%s\n", BgGreen, ColorBold, BoxCheck, ColorReset, fmt.Sprintf(" %s%s%s", ColorGreen, msg, ColorReset))
+	fmt.Fprintln(out)
 }
 
 // Warning prints a warning message
 func Warning(msg string) {
-	fmt.Printf("  %s⚠  %s%s\n", ColorYellow, msg, ColorReset)
-	fmt.Println()
+	fmt.Fprintf(out, "  %s⚠  %s%s\n", ColorYellow, msg, ColorReset)
+	fmt.Fprintln(out)
 }
 
 // Error prints an error message
 func Error(msg string, err error) {
 	if err != nil {
-		fmt.Printf("  %s%s %s: %v%s\n", ColorRed, BoxCross, msg, err, ColorReset)
+		fmt.Fprintf(out, "  %s%s %s: %v%s\n", ColorRed, BoxCross, msg, err, ColorReset)
 	} else {
-		fmt.Printf("  %s%s %s%s\n", ColorRed, BoxCross, msg, ColorReset)
+		fmt.Fprintf(out, "  %s%s %s%s\n", ColorRed, BoxCross, msg, ColorReset)
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
 }
 
 // ErrorWithTip prints an error with a helpful tip
 func ErrorWithTip(msg string, err error, tip string) {
 	Error(msg, err)
 	if tip != "" {
-		fmt.Printf("  %s💡 %s%s\n\n", ColorPink, tip, ColorReset)
+		fmt.Fprintf(out, "  %s💡 %s%s\n\n", ColorPink, tip, ColorReset)
 	}
 }
 
 // Info prints an info message
 func Info(msg string) {
-	fmt.Printf("  %s%s %s%s\n\n", ColorPink, BoxArrow, msg, ColorReset)
+	fmt.Fprintf(out, "  %s%s %s%s\n\n", ColorPink, BoxArrow, msg, ColorReset)
 }
 
 // Line prints a plain line
 func Line(msg string) {
-	fmt.Printf("  %s\n", msg)
+	fmt.Fprintf(out, "  %s\n", msg)
 }
 
 // Dim prints dimmed text
 func Dim(msg string) {
-	fmt.Printf("  %s%s%s\n", ColorDim, msg, ColorReset)
+	fmt.Fprintf(out, "  %s%s%s\n", ColorDim, msg, ColorReset)
 }
 
 // List prints a bulleted list
 func List(items []string) {
 	for _, item := range items {
-		fmt.Printf("  %s%s%s %s\n", ColorCyan, BoxDot, ColorReset, item)
+		fmt.Fprintf(out, "  %s%s%s %s\n", ColorCyan, BoxDot, ColorReset, item)
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
 }
 
 // Bold prints bold text
 func Bold(msg string) {
-	fmt.Printf("  %s%s%s\n", ColorBold, msg, ColorReset)
+	fmt.Fprintf(out, "  %s%s%s\n", ColorBold, msg, ColorReset)
 }
 
 // Print prints a plain message
 func Print(msg string) {
-	fmt.Println(msg)
+	fmt.Fprintln(out, msg)
 }
 
 // Printf prints a formatted message
 func Printf(format string, args ...interface{}) {
-	fmt.Printf(format, args...)
+	fmt.Fprintf(out, format, args...)
 }
 
 // Box is deprecated - use Header and Separator instead
@@ -127,50 +138,50 @@ func Printf(format string, args ...interface{}) {
 func Box(title string, lines []string) {
 	Header(title)
 	for _, line := range lines {
-		fmt.Println("  " + line)
+		fmt.Fprintln(out, "  " + line)
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
 }
 
 // Divider prints a horizontal line separator
 func Divider() {
-	fmt.Println(ColorDim + "  " + strings.Repeat("─", 50) + ColorReset)
+	fmt.Fprintln(out, ColorDim + "  " + strings.Repeat("─", 50) + ColorReset)
 }
 
 // SectionHeader prints a bold section title with divider
 func SectionHeader(title string) {
-	fmt.Println()
-	fmt.Println("  " + ColorPink + ColorBold + title + ColorReset)
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "  " + ColorPink + ColorBold + title + ColorReset)
 	Divider()
 }
 
 // Separator prints a decorative separator
 func Separator() {
-	fmt.Printf("  %s%s%s\n\n", ColorDim, strings.Repeat("─", 60), ColorReset)
+	fmt.Fprintf(out, "  %s%s%s\n\n", ColorDim, strings.Repeat("─", 60), ColorReset)
 }
 
 // Prompt prints a prompt with arrow
 func Prompt(msg string) {
-	fmt.Printf("  %s→%s %s: ", ColorPink, ColorReset, msg)
+	fmt.Fprintf(out, "  %s→%s %s: ", ColorPink, ColorReset, msg)
 }
 
 // TaskHeader prints a task header with divider
 func TaskHeader(taskNum int, title string) {
-	fmt.Println()
-	fmt.Printf("  %s📋 Task %d: %s%s%s\n", ColorPink, taskNum, ColorBold, title, ColorReset)
-	fmt.Printf("  %s%s%s\n\n", ColorDim, strings.Repeat("─", 50), ColorReset)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  %s📋 Task %d: %s%s%s\n", ColorPink, taskNum, ColorBold, title, ColorReset)
+	fmt.Fprintf(out, "  %s%s%s\n\n", ColorDim, strings.Repeat("─", 50), ColorReset)
 }
 
 // CheckHeader prints check header
 func CheckHeader(taskNum int, title string) {
-	fmt.Println()
-	fmt.Printf("  %s🔍 Checking Task %d: %s%s\n\n",
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  %s🔍 Checking Task %d: %s%s\n\n",
 		ColorPink, taskNum, title, ColorReset)
 }
 
 // CheckPass prints a passing check
 func CheckPass(name string, reason string) {
-	fmt.Printf("  %s✓%s %s%s%s%s\n",
+	fmt.Fprintf(out, "  %s✓%s %s%s%s%s\n",
 		ColorGreen, ColorReset,
 		ColorBold, name, ColorReset,
 		fmt.Sprintf(" %s%s%s", ColorDim, reason, ColorReset))
@@ -178,65 +189,101 @@ func CheckPass(name string, reason string) {
 
 // CheckFail prints a failing check
 func CheckFail(name string, reason string) {
-	fmt.Printf("  %s✗%s %s%s%s%s\n",
+	fmt.Fprintf(out, "  %s✗%s %s%s%s%s\n",
 		ColorRed, ColorReset,
 		ColorBold, name, ColorReset,
 		fmt.Sprintf(" %s%s%s", ColorDim, reason, ColorReset))
 }
 
+// CheckUpdated prints a golden rewritten instead of checked
+func CheckUpdated(name string, reason string) {
+	fmt.Fprintf(out, "  %s↻%s %s%s%s%s\n",
+		ColorBlue, ColorReset,
+		ColorBold, name, ColorReset,
+		fmt.Sprintf(" %s%s%s", ColorDim, reason, ColorReset))
+}
+
+// CheckDetail prints multi-line detail (such as a diff) under a check
+func CheckDetail(detail string) {
+	for _, line := range strings.Split(strings.TrimRight(detail, "\n"), "\n") {
+		color := ColorDim
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "@@"):
+			color = ColorCyan
+		case strings.HasPrefix(line, "+"):
+			color = ColorGreen
+		case strings.HasPrefix(line, "-"):
+			color = ColorRed
+		}
+		fmt.Fprintf(out, "      %s%s%s\n", color, line, ColorReset)
+	}
+	fmt.Fprintln(out)
+}
+
 // CheckSummaryPass prints passing summary
 func CheckSummaryPass(count int) {
-	fmt.Println()
-	fmt.Printf("  %s🎉 All %d checks passed!%s\n\n",
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  %s🎉 All %d checks passed!%s\n\n",
 		ColorGreen, count, ColorReset)
 }
 
 // CheckSummaryFail prints failing summary
 func CheckSummaryFail(passed, failed int) {
-	fmt.Println()
-	fmt.Printf("  %s⚠️  Results: %d passed, %d failed%s\n\n",
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  %s⚠️  Results: %d passed, %d failed%s\n\n",
 		ColorYellow, passed, failed, ColorReset)
 }
 
 // CommandHint prints a command suggestion
 func CommandHint(label, command string) {
-	fmt.Printf("  %s→ %s:%s %s%s%s\n\n",
+	fmt.Fprintf(out, "  %s→ %s:%s %s%s%s\n\n",
 		ColorPink, label, ColorReset,
 		ColorCyan, command, ColorReset)
 }
 
 // Step prints a numbered step
 func Step(num int, text string) {
-	fmt.Printf("    %s%d.%s %s\n", ColorYellow, num, ColorReset, text)
+	fmt.Fprintf(out, "    %s%d.%s %s\n", ColorYellow, num, ColorReset, text)
 }
 
 // File prints a file item
 func File(path string) {
-	fmt.Printf("    %s📄 %s%s\n", ColorGreen, path, ColorReset)
+	fmt.Fprintf(out, "    %s📄 %s%s\n", ColorGreen, path, ColorReset)
 }
 
 // Newline prints a blank line
 func Newline() {
-	fmt.Println()
+	fmt.Fprintln(out)
+}
+
+// ClearScreen clears the terminal and moves the cursor home so output can be
+// redrawn in place
+func ClearScreen() {
+	fmt.Fprint(out, "\033[H\033[2J")
+}
+
+// Bell rings the terminal bell
+func Bell() {
+	fmt.Fprint(out, "\a")
 }
 
 // ExplainHeader prints explain command header with attempt number
 func ExplainHeader(attemptNum int, taskTitle string) {
-	fmt.Println()
+	fmt.Fprintln(out)
 	if attemptNum == 1 {
-		fmt.Printf("  %s💡 Getting hints for: %s%s\n\n", 
+		fmt.Fprintf(out, "  %s💡 Getting hints for: %s%s\n\n", 
 			ColorPink, taskTitle, ColorReset)
 	} else if attemptNum == 2 {
-		fmt.Printf("  %s💡 Getting more specific hints (attempt #%d)%s\n", 
+		fmt.Fprintf(out, "  %s💡 Getting more specific hints (attempt #%d)%s\n", 
 			ColorPink, attemptNum, ColorReset)
-		fmt.Printf("  %s   Task: %s%s\n\n", 
+		fmt.Fprintf(out, "  %s   Task: %s%s\n\n", 
 			ColorDim, taskTitle, ColorReset)
 	} else {
-		fmt.Printf("  %s💡 Getting direct guidance (attempt #%d)%s\n", 
+		fmt.Fprintf(out, "  %s💡 Getting direct guidance (attempt #%d)%s\n", 
 			ColorPink, attemptNum, ColorReset)
-		fmt.Printf("  %s   Task: %s%s\n", 
+		fmt.Fprintf(out, "  %s   Task: %s%s\n", 
 			ColorDim, taskTitle, ColorReset)
-		fmt.Printf("  %s   Don't worry, we'll be more direct this time!%s\n\n", 
+		fmt.Fprintf(out, "  %s   Don't worry, we'll be more direct this time!%s\n\n", 
 			ColorDim, ColorReset)
 	}
 }
@@ -244,23 +291,23 @@ func ExplainHeader(attemptNum int, taskTitle string) {
 // AnnotationSummary prints annotation results
 func AnnotationSummary(count int) {
 	if count > 0 {
-		fmt.Printf("  %s✓ Added %d inline comment(s) to your code%s\n\n",
+		fmt.Fprintf(out, "  %s✓ Added %d inline comment(s) to your code%s\n\n",
 			ColorGreen, count, ColorReset)
 	} else {
-		fmt.Printf("  %s✓ Your code looks good, no additional comments needed%s\n\n",
+		fmt.Fprintf(out, "  %s✓ Your code looks good, no additional comments needed%s\n\n",
 			ColorGreen, ColorReset)
 	}
 }
 
 // HintSummary prints hint results
 func HintSummary(count int) {
-	fmt.Printf("  %s✓ Added %d hint(s) to your code%s\n\n", 
+	fmt.Fprintf(out, "  %s✓ Added %d hint(s) to your code%s\n\n", 
 		ColorGreen, count, ColorReset)
 }
 
 // FileLocation prints file and line number
 func FileLocation(file string, line int) {
-	fmt.Printf("    %s%s:%d%s\n", ColorDim, file, line, ColorReset)
+	fmt.Fprintf(out, "    %s%s:%d%s\n", ColorDim, file, line, ColorReset)
 }
 
 // Spinner represents a loading spinner
@@ -292,7 +339,7 @@ func (s *Spinner) Start() {
 			case <-s.stop:
 				return
 			case <-ticker.C:
-				fmt.Printf("\r  %s%s %s%s", ColorCyan, s.frames[s.current], s.msg, ColorReset)
+				fmt.Fprintf(out, "\r  %s%s %s%s", ColorCyan, s.frames[s.current], s.msg, ColorReset)
 				s.current = (s.current + 1) % len(s.frames)
 			}
 		}
@@ -302,5 +349,5 @@ func (s *Spinner) Start() {
 // Stop stops the spinner and clears the line
 func (s *Spinner) Stop() {
 	s.stop <- true
-	fmt.Print("\r\033[K")
+	fmt.Fprint(out, "\r\033[K")
 }
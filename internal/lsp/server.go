@@ -0,0 +1,174 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// CodeServerNotInitialized answers requests sent before initialize
+const CodeServerNotInitialized = -32002
+
+// ErrExitWithoutShutdown is returned by Serve when the client sent exit
+// without asking for shutdown first
+var ErrExitWithoutShutdown = errors.New("exit without shutdown")
+
+// Handler supplies what the server publishes. Paths are file paths decoded
+// from document URIs. Methods are called one at a time from the read loop,
+// so slow work such as running checks belongs on another goroutine.
+type Handler interface {
+	Initialize(root string) error
+	Initialized()
+	DidSave(path string)
+	Hover(path string, pos Position) (*Hover, error)
+	CodeActions(path string, params CodeActionParams) ([]CodeAction, error)
+	ExecuteCommand(command string, args []json.RawMessage) error
+	Commands() []string
+	Shutdown()
+}
+
+// Server dispatches LSP messages from a Conn to a Handler and lets the
+// handler notify the client
+type Server struct {
+	conn *Conn
+	name string
+}
+
+func NewServer(conn *Conn, name string) *Server {
+	return &Server{conn: conn, name: name}
+}
+
+// PublishDiagnostics replaces the diagnostics the client shows for path
+func (s *Server) PublishDiagnostics(path string, diagnostics []Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	return s.conn.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: PathToURI(path), Diagnostics: diagnostics})
+}
+
+// ShowMessage pops up a message in the editor
+func (s *Server) ShowMessage(kind MessageType, message string) error {
+	return s.conn.Notify("window/showMessage", ShowMessageParams{Type: kind, Message: message})
+}
+
+// LogMessage writes to the editor's log for this server
+func (s *Server) LogMessage(kind MessageType, message string) error {
+	return s.conn.Notify("window/logMessage", ShowMessageParams{Type: kind, Message: message})
+}
+
+// Serve handles messages until the client exits or closes the stream
+func (s *Server) Serve(h Handler) error {
+	initialized := false
+	shutdown := false
+
+	for {
+		msg, err := s.conn.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var respErr *ResponseError
+		if errors.As(err, &respErr) {
+			s.conn.Reply(nil, nil, respErr)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if msg.ID == nil {
+			switch msg.Method {
+			case "initialized":
+				h.Initialized()
+			case "textDocument/didSave":
+				var params TextDocumentParams
+				if json.Unmarshal(msg.Params, &params) == nil && initialized {
+					h.DidSave(URIToPath(params.TextDocument.URI))
+				}
+			case "exit":
+				if !shutdown {
+					return ErrExitWithoutShutdown
+				}
+				return nil
+			}
+			continue
+		}
+
+		if !initialized && msg.Method != "initialize" {
+			s.conn.Reply(msg.ID, nil, &ResponseError{Code: CodeServerNotInitialized, Message: "server not initialized"})
+			continue
+		}
+		result, err := s.handle(h, msg)
+		if msg.Method == "initialize" && err == nil {
+			initialized = true
+		}
+		if msg.Method == "shutdown" {
+			shutdown = true
+		}
+		if err := s.conn.Reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(h Handler, msg *Message) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		var params InitializeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &ResponseError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		root := URIToPath(params.RootURI)
+		if root == "" {
+			root = params.RootPath
+		}
+		if err := h.Initialize(root); err != nil {
+			return nil, err
+		}
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:       TextDocumentSyncOptions{OpenClose: true, Save: SaveOptions{}},
+				HoverProvider:          true,
+				CodeActionProvider:     true,
+				ExecuteCommandProvider: ExecuteCommandOptions{Commands: h.Commands()},
+			},
+			ServerInfo: ServerInfo{Name: s.name},
+		}, nil
+
+	case "shutdown":
+		h.Shutdown()
+		return nil, nil
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &ResponseError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		hover, err := h.Hover(URIToPath(params.TextDocument.URI), params.Position)
+		if hover == nil || err != nil {
+			return nil, err
+		}
+		return hover, nil
+
+	case "textDocument/codeAction":
+		var params CodeActionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &ResponseError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		actions, err := h.CodeActions(URIToPath(params.TextDocument.URI), params)
+		if err != nil {
+			return nil, err
+		}
+		if actions == nil {
+			actions = []CodeAction{}
+		}
+		return actions, nil
+
+	case "workspace/executeCommand":
+		var params ExecuteCommandParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, &ResponseError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		return nil, h.ExecuteCommand(params.Command, params.Arguments)
+	}
+	return nil, &ResponseError{Code: CodeMethodNotFound, Message: "method not supported: " + msg.Method}
+}
@@ -0,0 +1,155 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type fakeHandler struct {
+	root  string
+	saved []string
+	ready bool
+}
+
+func (h *fakeHandler) Initialize(root string) error { h.root = root; return nil }
+func (h *fakeHandler) Initialized()                 { h.ready = true }
+func (h *fakeHandler) DidSave(path string)          { h.saved = append(h.saved, path) }
+func (h *fakeHandler) Commands() []string           { return []string{"fake.run"} }
+func (h *fakeHandler) Shutdown()                    {}
+
+func (h *fakeHandler) Hover(path string, pos Position) (*Hover, error) {
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: fmt.Sprintf("%s:%d", path, pos.Line)}}, nil
+}
+
+func (h *fakeHandler) CodeActions(path string, params CodeActionParams) ([]CodeAction, error) {
+	return nil, nil
+}
+
+func (h *fakeHandler) ExecuteCommand(command string, args []json.RawMessage) error {
+	if command != "fake.run" {
+		return &ResponseError{Code: CodeInvalidParams, Message: "unknown command"}
+	}
+	return nil
+}
+
+// frame encodes messages the way a client sends them
+func frame(messages ...string) io.Reader {
+	var b bytes.Buffer
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	return &b
+}
+
+// readAll decodes every message the server wrote
+func readAll(t *testing.T, r io.Reader) []*Message {
+	t.Helper()
+	conn := NewConn(r, io.Discard)
+	var messages []*Message
+	for {
+		msg, err := conn.Read()
+		if err == io.EOF {
+			return messages
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, msg)
+	}
+}
+
+func testPath() string {
+	if runtime.GOOS == "windows" {
+		return `C:\work\main.go`
+	}
+	return "/work/main.go"
+}
+
+func TestURIRoundTrip(t *testing.T) {
+	path := testPath()
+	uri := PathToURI(path)
+	if !strings.HasPrefix(uri, "file:///") {
+		t.Errorf("Expected a file URI, got %s", uri)
+	}
+	if got := URIToPath(uri); got != path {
+		t.Errorf("Expected %s back, got %s", path, got)
+	}
+	if got := URIToPath("untitled:Untitled-1"); got != "" {
+		t.Errorf("Expected no path for an untitled document, got %s", got)
+	}
+}
+
+func TestServe(t *testing.T) {
+	uri := PathToURI(testPath())
+	input := frame(
+		`{"jsonrpc":"2.0","id":0,"method":"textDocument/hover","params":{}}`,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":"`+PathToURI(testPath())+`"}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didSave","params":{"textDocument":{"uri":"`+uri+`"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"`+uri+`"},"position":{"line":3,"character":0}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"workspace/executeCommand","params":{"command":"other"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"textDocument/definition","params":{}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+	var output bytes.Buffer
+	h := &fakeHandler{}
+	if err := NewServer(NewConn(input, &output), "test").Serve(h); err != nil {
+		t.Fatalf("Expected a clean exit, got %v", err)
+	}
+
+	if !h.ready || h.root != testPath() || len(h.saved) != 1 || h.saved[0] != testPath() {
+		t.Errorf("Unexpected handler calls: %+v", h)
+	}
+
+	replies := readAll(t, &output)
+	if len(replies) != 6 {
+		t.Fatalf("Expected 6 replies, got %d", len(replies))
+	}
+	if replies[0].Error == nil || replies[0].Error.Code != CodeServerNotInitialized {
+		t.Errorf("Expected requests before initialize to fail, got %+v", replies[0])
+	}
+	result, _ := json.Marshal(replies[1].Result)
+	if !strings.Contains(string(result), `"hoverProvider":true`) || !strings.Contains(string(result), `"fake.run"`) {
+		t.Errorf("Expected capabilities with the handler's commands, got %s", result)
+	}
+	hover, _ := json.Marshal(replies[2].Result)
+	if !strings.Contains(string(hover), ":3") {
+		t.Errorf("Expected hover text for line 3, got %s", hover)
+	}
+	if replies[3].Error == nil || replies[3].Error.Code != CodeInvalidParams {
+		t.Errorf("Expected the unknown command to fail, got %+v", replies[3])
+	}
+	if replies[4].Error == nil || replies[4].Error.Code != CodeMethodNotFound {
+		t.Errorf("Expected unsupported methods to fail, got %+v", replies[4])
+	}
+	if replies[5].Error != nil {
+		t.Errorf("Expected shutdown to succeed, got %+v", replies[5].Error)
+	}
+}
+
+func TestServeExitWithoutShutdown(t *testing.T) {
+	input := frame(`{"jsonrpc":"2.0","method":"exit"}`)
+	if err := NewServer(NewConn(input, io.Discard), "test").Serve(&fakeHandler{}); err != ErrExitWithoutShutdown {
+		t.Errorf("Expected ErrExitWithoutShutdown, got %v", err)
+	}
+}
+
+func TestServeParseError(t *testing.T) {
+	input := frame(`{"jsonrpc":"2.0","id":`, `{"jsonrpc":"2.0","method":"exit"}`)
+	var output bytes.Buffer
+	NewServer(NewConn(input, &output), "test").Serve(&fakeHandler{})
+
+	if !strings.Contains(output.String(), `"id":null`) {
+		t.Errorf("Expected the parse error to be answered with a null id, got %s", output.String())
+	}
+	replies := readAll(t, &output)
+	if len(replies) != 1 || replies[0].Error == nil || replies[0].Error.Code != CodeParseError {
+		t.Errorf("Expected a single parse error, got %+v", replies)
+	}
+}
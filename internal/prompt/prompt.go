@@ -368,13 +368,13 @@ func ConfirmComplete(completedTasks, totalTasks int, allCompleted bool) (bool, b
 		lines = append(lines, fmt.Sprintf("%s🎉 All %d tasks completed!%s", 
 			format.ColorGreen, totalTasks, format.ColorReset))
 		lines = append(lines, "")
-		lines = append(lines, fmt.Sprintf("%sThis will remove the .quest folder%s", 
+		lines = append(lines, fmt.Sprintf("%sThis will archive the quest, see it again with quest history%s", 
 			format.ColorDim, format.ColorReset))
 	} else {
 		lines = append(lines, fmt.Sprintf("%sProgress: %d/%d tasks completed%s", 
 			format.ColorYellow, completedTasks, totalTasks, format.ColorReset))
 		lines = append(lines, "")
-		lines = append(lines, fmt.Sprintf("%sThis will remove the .quest folder%s", 
+		lines = append(lines, fmt.Sprintf("%sThis will archive the quest, see it again with quest history%s", 
 			format.ColorDim, format.ColorReset))
 	}
 	
@@ -397,7 +397,7 @@ func ConfirmComplete(completedTasks, totalTasks int, allCompleted bool) (bool, b
 	
 	if input == "y" || input == "yes" {
 		format.Newline()
-		showLoading("Cleaning up", 400)
+		showLoading("Archiving", 400)
 		return true, false, nil
 	}
 	
@@ -420,3 +420,35 @@ func showLoading(msg string, durationMs int) {
 	format.Printf("\r  %s%s%s %s    \n", format.ColorGreen, format.BoxCheck, format.ColorReset, msg)
 }
 
+
+// Interactive reports whether quest may ask questions: stdin is a terminal
+// and the CI environment variable isn't set
+func Interactive() bool {
+	if os.Getenv("CI") != "" {
+		return false
+	}
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	// /dev/null is a character device too, but nobody is there to answer
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
+}
+
+// Confirm asks a yes/no question, anything but yes is a no
+func Confirm(question string) (bool, error) {
+	format.Prompt(question)
+
+	reader := bufio.NewReader(os.Stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	format.Newline()
+
+	input = strings.TrimSpace(strings.ToLower(input))
+	return input == "y" || input == "yes", nil
+}
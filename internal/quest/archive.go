@@ -0,0 +1,318 @@
+package quest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// ArchiveSummaryFileName is written next to the plan and state of an
+// archived quest
+const ArchiveSummaryFileName = "summary.json"
+
+// ArchiveSummary is the final summary of a finished quest
+type ArchiveSummary struct {
+	ID          string       `json:"id"`
+	Path        string       `json:"path"`
+	Quest       string       `json:"quest"`     // name the quest had in its workspace
+	Workspace   string       `json:"workspace"` // where the quest was played
+	Journey     string       `json:"journey"`
+	CompletedAt time.Time    `json:"completedAt"`
+	Completed   int          `json:"completed"`
+	Total       int          `json:"total"`
+	Finished    bool         `json:"finished"`
+	Stats       *StatsReport `json:"stats,omitempty"`
+}
+
+// ArchiveDir is where finished quests are kept, under $XDG_DATA_HOME or
+// ~/.local/share
+func ArchiveDir() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "quest", "archive"), nil
+}
+
+// archiveQuest moves the selected quest with its final summary into the
+// archive and returns where it went
+func archiveQuest(plan *types.Plan, state *types.State) (*ArchiveSummary, error) {
+	root, err := ArchiveDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	id := now.Format("20060102-150405") + "-" + slug(plan.Journey.Name)
+	workspace, _ := os.Getwd()
+	summary := &ArchiveSummary{
+		ID:          id,
+		Path:        filepath.Join(root, id),
+		Quest:       QuestName,
+		Workspace:   workspace,
+		Journey:     plan.Journey.Name,
+		CompletedAt: now,
+		Completed:   len(state.CompletedTaskIDs),
+		Total:       countTasks(*plan),
+		Finished:    ifCompletedPlan(*plan, *state),
+		Stats:       buildStats(plan, state, now),
+	}
+	if _, err := os.Stat(summary.Path); err == nil {
+		return nil, fmt.Errorf("%s already exists", summary.Path)
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	summaryPath := filepath.Join(QuestDir, ArchiveSummaryFileName)
+	if err := writeFileAtomic(summaryPath, data, 0644); err != nil {
+		return nil, err
+	}
+	if err := moveDir(QuestDir, summary.Path); err != nil {
+		os.Remove(summaryPath)
+		return nil, err
+	}
+	return summary, nil
+}
+
+// slug turns a journey name into something safe for a folder name
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	s := strings.TrimSuffix(b.String(), "-")
+	if s == "" {
+		return "quest"
+	}
+	return s
+}
+
+// moveDir renames src to dst, copying when they are on different file
+// systems, as the archive in the home folder often is
+func moveDir(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyDir(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		return copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+// ListArchives returns the archived quests, most recently finished first
+func ListArchives() ([]ArchiveSummary, error) {
+	root, err := ArchiveDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var archives []ArchiveSummary
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(root, entry.Name())
+		data, err := os.ReadFile(filepath.Join(path, ArchiveSummaryFileName))
+		if err != nil {
+			continue
+		}
+		var summary ArchiveSummary
+		if json.Unmarshal(data, &summary) != nil {
+			continue
+		}
+		// The folder may have been moved since it was archived
+		summary.ID = entry.Name()
+		summary.Path = path
+		archives = append(archives, summary)
+	}
+	sort.SliceStable(archives, func(i, j int) bool {
+		return archives[i].CompletedAt.After(archives[j].CompletedAt)
+	})
+	return archives, nil
+}
+
+// findArchive looks an archived quest up by its id or its number in
+// quest history
+func findArchive(ref string) (*ArchiveSummary, error) {
+	archives, err := ListArchives()
+	if err != nil {
+		return nil, err
+	}
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(archives) {
+		return &archives[n-1], nil
+	}
+	for i := range archives {
+		if archives[i].ID == ref {
+			return &archives[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no archived quest '%s': %w", ref, fs.ErrNotExist)
+}
+
+func RunHistory(cmd *cobra.Command, args []string) (err error) {
+	report := newReport(cmd, "history")
+	defer func() { err = report.finish(err) }()
+
+	if len(args) == 1 {
+		return showArchive(report, args[0])
+	}
+
+	archives, err := ListArchives()
+	if err != nil {
+		format.Error("Failed to read the quest archive", err)
+		return exitWith(ExitInternal, err)
+	}
+	if len(archives) == 0 {
+		format.Info("No finished quests yet. 'quest complete' archives a quest when you're done")
+		return nil
+	}
+
+	format.Header("Quest History")
+	for i, archive := range archives {
+		mark := " "
+		if archive.Finished {
+			mark = format.ColorGreen + "✓" + format.ColorReset
+		}
+		format.Line(fmt.Sprintf("%s%3d%s %s %s%-30s%s %d/%d tasks · %s",
+			format.ColorDim, i+1, format.ColorReset, mark,
+			format.ColorBold, truncate(archive.Journey, 30), format.ColorReset,
+			archive.Completed, archive.Total, archive.CompletedAt.Local().Format("2006-01-02 15:04")))
+		archive.Stats = nil
+		report.Archives = append(report.Archives, archive)
+	}
+	format.Newline()
+	format.CommandHint("Inspect a quest with", "quest history <number>")
+	format.CommandHint("Pick one up again with", "quest reopen <number>")
+	return nil
+}
+
+// showArchive prints the final summary and stats of an archived quest
+func showArchive(report *Report, ref string) error {
+	archive, err := findArchive(ref)
+	if err != nil {
+		format.ErrorWithTip("Archived quest not found", err, "Run 'quest history' to see your finished quests")
+		return commandError(err)
+	}
+	state, err := loadStateFile(filepath.Join(archive.Path, StateFileName))
+	if err != nil {
+		format.ErrorWithTip("Failed to load the archived state", err, loadTip(err))
+		return commandError(err)
+	}
+	plan, err := loadPlanFile(filepath.Join(archive.Path, PlanFileName))
+	if err != nil {
+		format.ErrorWithTip("Failed to load the archived plan", err, loadTip(err))
+		return commandError(err)
+	}
+
+	report.Archives = []ArchiveSummary{*archive}
+	report.Progress = summaryReport(plan, state)
+	report.Stats = archive.Stats
+
+	printSummary(*plan, *state)
+	if archive.Stats != nil {
+		printStats(archive.Stats)
+	}
+	format.Dim(fmt.Sprintf("Completed %s in %s", archive.CompletedAt.Local().Format("2006-01-02 15:04"), archive.Workspace))
+	format.Dim(fmt.Sprintf("Archived in %s", archive.Path))
+	format.Newline()
+	return nil
+}
+
+func RunReopen(cmd *cobra.Command, args []string) error {
+	archive, err := findArchive(args[0])
+	if err != nil {
+		format.ErrorWithTip("Archived quest not found", err, "Run 'quest history' to see your finished quests")
+		return commandError(err)
+	}
+
+	name, _ := cmd.Flags().GetString("name")
+	if name == "" {
+		name = archive.Quest
+	}
+	if err := ValidateQuestName(name); err != nil {
+		format.Error("Invalid quest name", err)
+		return exitWith(ExitInternal, err)
+	}
+	UseQuest(name)
+	if _, err := os.Stat(QuestDir); err == nil {
+		format.ErrorWithTip(fmt.Sprintf("This workspace already has a quest named '%s'", name), nil, "Reopen it under another name with 'quest reopen "+args[0]+" --name <name>'")
+		return exitWith(ExitFail, fs.ErrExist)
+	}
+
+	if err := os.MkdirAll(QuestFolderPath, 0755); err != nil {
+		format.ErrorWithTip(fmt.Sprintf("Failed to create %s", QuestFolderPath), err, "Check folder permissions")
+		return exitWith(ExitInternal, err)
+	}
+	if err := moveDir(archive.Path, QuestDir); err != nil {
+		format.ErrorWithTip("Failed to restore the quest", err, fmt.Sprintf("The archive is still in %s", archive.Path))
+		return exitWith(ExitInternal, err)
+	}
+	os.Remove(filepath.Join(QuestDir, ArchiveSummaryFileName))
+	if err := SetCurrentQuest(name); err != nil {
+		format.ErrorWithTip("Failed to switch to the reopened quest", err, "Run 'quest switch "+name+"'")
+		return exitWith(ExitInternal, err)
+	}
+
+	format.Success(fmt.Sprintf("Reopened '%s' as quest '%s'", archive.Journey, name))
+	if wd, _ := os.Getwd(); archive.Workspace != "" && archive.Workspace != wd {
+		format.Dim(fmt.Sprintf("It was played in %s, its files are not copied here", archive.Workspace))
+	}
+	format.CommandHint("See where you left off with", "quest summary")
+	return nil
+}
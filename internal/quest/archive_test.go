@@ -0,0 +1,94 @@
+package quest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompleteArchivesQuest(t *testing.T) {
+	chdirTemp(t)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Cleanup(func() { UseQuest(DefaultQuestName) })
+	writeNamedQuest(t, "api", "Web API")
+	SetCurrentQuest("api")
+	state, _ := LoadState()
+	state.CompletedTaskIDs = []string{"only"}
+	UploadState(state)
+
+	// Another command using the quest keeps it from being archived under it
+	old := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = old })
+	host, _ := os.Hostname()
+	writeLock(t, lockInfo{PID: os.Getppid(), Host: host, AcquiredAt: time.Now()})
+	if err := RunCompete(testCommand(t, "complete", "--yes"), nil); !errors.Is(err, errLocked) {
+		t.Fatalf("Expected complete to wait for the lock, got %v", err)
+	}
+	os.Remove(LockFilePath)
+
+	if err := RunCompete(testCommand(t, "complete", "--yes"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(QuestFolderName); !os.IsNotExist(err) {
+		t.Error("Expected .quest to be gone once its only quest was archived")
+	}
+
+	archives, err := ListArchives()
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("Expected one archived quest, got %v (%v)", archives, err)
+	}
+	archive := archives[0]
+	if archive.Quest != "api" || archive.Journey != "Web API" || archive.Total != 1 || !archive.Finished || archive.Stats == nil {
+		t.Errorf("Unexpected archive summary: %+v", archive)
+	}
+	for _, name := range []string{StateFileName, PlanFileName, ArchiveSummaryFileName, filepath.Base(EventsFilePath)} {
+		if _, err := os.Stat(filepath.Join(archive.Path, name)); err != nil {
+			t.Errorf("Expected %s in the archive: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(archive.Path, filepath.Base(LockFilePath))); !os.IsNotExist(err) {
+		t.Error("Expected the lock to be left behind")
+	}
+
+	report, err := captureReport(t, testCommand(t, "history"), RunHistory, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Archives) != 1 || report.Progress == nil || report.Progress.Journey != "Web API" {
+		t.Errorf("Expected history to show the archived quest, got %+v", report)
+	}
+}
+
+func TestReopenRestoresQuest(t *testing.T) {
+	chdirTemp(t)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Cleanup(func() { UseQuest(DefaultQuestName) })
+	writeNamedQuest(t, "api", "Web API")
+	if err := RunCompete(testCommand(t, "complete", "--yes"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	writeNamedQuest(t, "api", "Another API")
+	if err := RunReopen(testCommand(t, "reopen"), []string{"1"}); ExitCode(err) != ExitFail {
+		t.Errorf("Expected reopening over an existing quest to be refused, got %v", err)
+	}
+	if err := RunReopen(testCommand(t, "reopen", "--name", "old-api"), []string{"1"}); err != nil {
+		t.Fatal(err)
+	}
+	if CurrentQuest() != "old-api" {
+		t.Errorf("Expected the reopened quest to be current, got %s", CurrentQuest())
+	}
+	plan, err := LoadPlan()
+	if err != nil || plan.Journey.Name != "Web API" {
+		t.Errorf("Expected the archived plan back, got %+v (%v)", plan, err)
+	}
+	if _, err := os.Stat(filepath.Join(QuestDir, ArchiveSummaryFileName)); !os.IsNotExist(err) {
+		t.Error("Expected the archive summary to be dropped")
+	}
+	if archives, _ := ListArchives(); len(archives) != 0 {
+		t.Errorf("Expected the archive to be emptied, got %v", archives)
+	}
+}
@@ -0,0 +1,350 @@
+package quest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/spf13/cobra"
+)
+
+// bundleFormat is the layout version of progress bundles, separate from the
+// schema version of the state and plan inside them
+const bundleFormat = 1
+
+// Inside a bundle the manifest comes first, followed by the files of the
+// quest folder under quest/
+const (
+	bundleManifestName = "manifest.json"
+	bundleQuestPrefix  = "quest/"
+)
+
+// DefaultBundleName is where quest export writes without a file name
+const DefaultBundleName = "progress.tar.gz"
+
+// maxBundleSize caps how much an imported bundle may unpack to
+const maxBundleSize = 512 << 20
+
+// errBadBundle is wrapped by every reason a bundle is refused
+var errBadBundle = errors.New("invalid progress bundle")
+
+// bundleManifest describes a bundle and holds the checksum of each file
+type bundleManifest struct {
+	Format        int               `json:"format"`
+	SchemaVersion int               `json:"schemaVersion"`
+	Quest         string            `json:"quest"`
+	Journey       string            `json:"journey"`
+	Completed     int               `json:"completed"`
+	Total         int               `json:"total"`
+	ExportedAt    time.Time         `json:"exportedAt"`
+	Files         map[string]string `json:"files"` // path in the quest folder -> sha256
+}
+
+// exportedFiles lists the files of the selected quest that belong in a
+// bundle. The lock, the check cache, migration backups and temporary files
+// stay behind: they mean nothing on another machine.
+func exportedFiles() ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(QuestDir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(QuestDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		name := entry.Name()
+		if rel != "." && strings.HasPrefix(name, ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !entry.Type().IsRegular() {
+			return nil
+		}
+		if rel == filepath.Base(LockFilePath) || rel == filepath.Base(CacheFilePath) || strings.HasSuffix(name, ".bak") {
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeBundle packs the manifest and files into a gzipped tarball
+func writeBundle(bundle string, manifest bundleManifest, files map[string][]byte) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	add := func(name string, data []byte) error {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: manifest.ExportedAt, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := add(bundleManifestName, data); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := add(bundleQuestPrefix+name, files[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(bundle, buf.Bytes(), 0644)
+}
+
+// readBundle unpacks a bundle in memory and verifies it: a known format, a
+// schema this quest can read, safe paths and a matching checksum for every
+// file the manifest lists and no others
+func readBundle(bundle string) (*bundleManifest, map[string][]byte, error) {
+	f, err := os.Open(bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errBadBundle, err)
+	}
+	tr := tar.NewReader(gz)
+
+	var manifest *bundleManifest
+	files := map[string][]byte{}
+	var total int64
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", errBadBundle, err)
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, nil, fmt.Errorf("%w: %s is not a regular file", errBadBundle, header.Name)
+		}
+		total += header.Size
+		if total > maxBundleSize {
+			return nil, nil, fmt.Errorf("%w: unpacks to more than %d MB", errBadBundle, maxBundleSize>>20)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, header.Size))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", errBadBundle, err)
+		}
+
+		if header.Name == bundleManifestName {
+			manifest = &bundleManifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, nil, fmt.Errorf("%w: %s: %v", errBadBundle, bundleManifestName, err)
+			}
+			continue
+		}
+		name := strings.TrimPrefix(header.Name, bundleQuestPrefix)
+		if name == header.Name || name == "" || path.IsAbs(name) || path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, nil, fmt.Errorf("%w: unexpected file %s", errBadBundle, header.Name)
+		}
+		files[name] = data
+	}
+
+	if manifest == nil {
+		return nil, nil, fmt.Errorf("%w: no %s", errBadBundle, bundleManifestName)
+	}
+	if manifest.Format != bundleFormat {
+		return nil, nil, fmt.Errorf("%w: format %d, this quest reads format %d", errBadBundle, manifest.Format, bundleFormat)
+	}
+	if manifest.SchemaVersion > Version {
+		return nil, nil, fmt.Errorf("%w: exported with schema version %d, this quest supports up to %d", ErrNewerSchema, manifest.SchemaVersion, Version)
+	}
+	for name, sum := range manifest.Files {
+		data, ok := files[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s is missing", errBadBundle, name)
+		}
+		if checksum(data) != sum {
+			return nil, nil, fmt.Errorf("%w: checksum mismatch for %s", errBadBundle, name)
+		}
+	}
+	for name := range files {
+		if _, ok := manifest.Files[name]; !ok {
+			return nil, nil, fmt.Errorf("%w: %s is not in the manifest", errBadBundle, name)
+		}
+	}
+	for _, name := range []string{StateFileName, PlanFileName} {
+		if _, ok := files[name]; !ok {
+			return nil, nil, fmt.Errorf("%w: no %s", errBadBundle, name)
+		}
+	}
+	return manifest, files, nil
+}
+
+func RunExport(cmd *cobra.Command, args []string) error {
+	unlock, err := lockForUpdate()
+	if err != nil {
+		return commandError(err)
+	}
+	defer unlock()
+
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
+		return commandError(err)
+	}
+	// Saving upgrades older files, so the bundle always has the current schema
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		format.ErrorWithTip("Failed to save quest data", err, "Check folder permissions")
+		return exitWith(ExitInternal, err)
+	}
+
+	target := DefaultBundleName
+	if len(args) == 1 {
+		target = args[0]
+	}
+	target = userPath(target)
+
+	names, err := exportedFiles()
+	if err != nil {
+		format.Error("Failed to read the quest folder", err)
+		return exitWith(ExitInternal, err)
+	}
+	manifest := bundleManifest{
+		Format:        bundleFormat,
+		SchemaVersion: Version,
+		Quest:         QuestName,
+		Journey:       plan.Journey.Name,
+		Completed:     len(state.CompletedTaskIDs),
+		Total:         plan.NumberOfTasks,
+		ExportedAt:    time.Now(),
+		Files:         map[string]string{},
+	}
+	files := map[string][]byte{}
+	var size int
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(QuestDir, filepath.FromSlash(name)))
+		if err != nil {
+			format.Error("Failed to read the quest folder", err)
+			return exitWith(ExitInternal, err)
+		}
+		files[name] = data
+		manifest.Files[name] = checksum(data)
+		size += len(data)
+	}
+
+	if err := writeBundle(target, manifest, files); err != nil {
+		format.ErrorWithTip("Failed to write the bundle", err, "Check that the folder exists and is writable")
+		return exitWith(ExitInternal, err)
+	}
+
+	format.Success(fmt.Sprintf("Exported quest '%s' to %s", QuestName, target))
+	format.Dim(fmt.Sprintf("%s · %d/%d tasks · %d files, %d KB before compression", manifest.Journey, manifest.Completed, manifest.Total, len(files), (size+1023)/1024))
+	format.CommandHint("On the other machine, run", "quest import "+filepath.Base(target))
+	return nil
+}
+
+func RunImport(cmd *cobra.Command, args []string) error {
+	source := userPath(args[0])
+	manifest, files, err := readBundle(source)
+	if err != nil {
+		tip := "Export the quest again with 'quest export'"
+		if errors.Is(err, ErrNewerSchema) {
+			tip = loadTip(err)
+		}
+		format.ErrorWithTip(fmt.Sprintf("Could not import %s", args[0]), err, tip)
+		return exitWith(ExitInternal, err)
+	}
+
+	name, _ := cmd.Flags().GetString("name")
+	if name == "" {
+		name = manifest.Quest
+	}
+	if err := ValidateQuestName(name); err != nil {
+		format.Error("Invalid quest name", err)
+		return exitWith(ExitInternal, err)
+	}
+	UseQuest(name)
+	if _, err := os.Stat(QuestDir); err == nil {
+		format.ErrorWithTip(fmt.Sprintf("This workspace already has a quest named '%s'", name), nil,
+			fmt.Sprintf("Import it next to that one with 'quest import %s --name <name>', or export and complete the existing quest first", args[0]))
+		return exitWith(ExitFail, fs.ErrExist)
+	}
+
+	// Unpack next to the target and rename it into place, so a failed
+	// import never leaves half a quest behind
+	if err := os.MkdirAll(QuestFolderPath, 0755); err != nil {
+		format.ErrorWithTip(fmt.Sprintf("Failed to create %s", QuestFolderPath), err, "Check folder permissions")
+		return exitWith(ExitInternal, err)
+	}
+	tmp, err := os.MkdirTemp(QuestFolderPath, ".import-*")
+	if err != nil {
+		format.ErrorWithTip("Failed to unpack the bundle", err, "Check folder permissions")
+		return exitWith(ExitInternal, err)
+	}
+	defer os.RemoveAll(tmp)
+	for file, data := range files {
+		target := filepath.Join(tmp, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			format.ErrorWithTip("Failed to unpack the bundle", err, "Check folder permissions")
+			return exitWith(ExitInternal, err)
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			format.ErrorWithTip("Failed to unpack the bundle", err, "Check folder permissions")
+			return exitWith(ExitInternal, err)
+		}
+	}
+	if err := os.Rename(tmp, QuestDir); err != nil {
+		format.ErrorWithTip("Failed to import the quest", err, "Check folder permissions")
+		return exitWith(ExitInternal, err)
+	}
+	if err := SetCurrentQuest(name); err != nil {
+		format.Warning(fmt.Sprintf("Could not make '%s' the current quest: %v", name, err))
+	}
+
+	format.Success(fmt.Sprintf("Imported '%s' as quest '%s'", manifest.Journey, name))
+	format.Dim(fmt.Sprintf("%d/%d tasks, exported %s", manifest.Completed, manifest.Total, manifest.ExportedAt.Local().Format("2006-01-02 15:04")))
+	format.CommandHint("See where you left off with", "quest summary")
+	return nil
+}
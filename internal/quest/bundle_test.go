@@ -0,0 +1,115 @@
+package quest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestExportImport(t *testing.T) {
+	chdirTemp(t)
+	t.Cleanup(func() { UseQuest(DefaultQuestName) })
+	writeNamedQuest(t, "api", "Web API")
+	logEvent(Event{Type: EventNext, Task: 1, TaskID: "only"})
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+	state, _ := LoadState()
+	takeSnapshot(state, types.Task{ID: "only", Artifacts: []string{"main.go"}}, SnapshotStart)
+	os.WriteFile(CacheFilePath, []byte("{}"), 0644)
+	os.WriteFile(StateFilePath+".v0.bak", []byte("{}"), 0644)
+
+	bundle := filepath.Join(t.TempDir(), "progress.tar.gz")
+	if err := RunExport(testCommand(t, "export"), []string{bundle}); err != nil {
+		t.Fatal(err)
+	}
+	manifest, files, err := readBundle(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Quest != "api" || manifest.Journey != "Web API" || manifest.SchemaVersion != Version {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+	for _, name := range []string{StateFileName, PlanFileName, "events.jsonl", "snapshots/index.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in the bundle, got %v", name, manifest.Files)
+		}
+	}
+	for _, name := range []string{"cache.json", "lock", StateFileName + ".v0.bak"} {
+		if _, ok := files[name]; ok {
+			t.Errorf("Expected %s to stay behind", name)
+		}
+	}
+
+	// Another machine
+	os.Chdir(t.TempDir())
+	UseQuest(DefaultQuestName)
+	if err := RunImport(testCommand(t, "import"), []string{bundle}); err != nil {
+		t.Fatal(err)
+	}
+	if CurrentQuest() != "api" {
+		t.Errorf("Expected the imported quest to be current, got %s", CurrentQuest())
+	}
+	plan, err := LoadPlan()
+	if err != nil || plan.Journey.Name != "Web API" {
+		t.Errorf("Expected the exported plan, got %+v (%v)", plan, err)
+	}
+	if events, err := ReadEvents(); err != nil || len(events) != 1 {
+		t.Errorf("Expected the activity log to come along, got %v (%v)", events, err)
+	}
+
+	os.WriteFile(StateFilePath, []byte(`{"version": 1, "currentTaskIndex": 1}`), 0644)
+	if err := RunImport(testCommand(t, "import"), []string{bundle}); ExitCode(err) != ExitFail {
+		t.Errorf("Expected importing over an existing quest to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(StateFilePath); string(data) != `{"version": 1, "currentTaskIndex": 1}` {
+		t.Errorf("Expected the existing quest to be left alone, got %s", data)
+	}
+	if err := RunImport(testCommand(t, "import", "--name", "api-copy"), []string{bundle}); err != nil {
+		t.Errorf("Expected importing under another name to work, got %v", err)
+	}
+}
+
+func TestReadBundleRejects(t *testing.T) {
+	dir := t.TempDir()
+	state := []byte(`{"version": 1}`)
+	good := func() bundleManifest {
+		return bundleManifest{Format: bundleFormat, SchemaVersion: Version, Quest: "api", ExportedAt: time.Now(),
+			Files: map[string]string{StateFileName: checksum(state), PlanFileName: checksum(state)}}
+	}
+	files := map[string][]byte{StateFileName: state, PlanFileName: state}
+
+	tampered := good()
+	tampered.Files[StateFileName] = checksum([]byte("other"))
+	newer := good()
+	newer.SchemaVersion = Version + 1
+	escaping := good()
+	escaping.Files["../escape"] = checksum(state)
+	escapingFiles := map[string][]byte{StateFileName: state, PlanFileName: state, "../escape": state}
+
+	for name, c := range map[string]struct {
+		manifest bundleManifest
+		files    map[string][]byte
+		want     error
+	}{
+		"checksum": {tampered, files, errBadBundle},
+		"newer":    {newer, files, ErrNewerSchema},
+		"escaping": {escaping, escapingFiles, errBadBundle},
+		"missing":  {good(), map[string][]byte{StateFileName: state}, errBadBundle},
+	} {
+		path := filepath.Join(dir, name+".tar.gz")
+		if err := writeBundle(path, c.manifest, c.files); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := readBundle(path); !errors.Is(err, c.want) {
+			t.Errorf("%s: expected %v, got %v", name, c.want, err)
+		}
+	}
+
+	os.WriteFile(filepath.Join(dir, "plain.tar.gz"), []byte("not a bundle"), 0644)
+	if _, _, err := readBundle(filepath.Join(dir, "plain.tar.gz")); !errors.Is(err, errBadBundle) {
+		t.Errorf("Expected a file that isn't a bundle to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package quest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+// cacheVersion is bumped whenever cached results may no longer be trusted
+const cacheVersion = 2
+
+// RuleCache remembers rule results together with hashes of the files each
+// rule read, so a rule only runs again once one of its inputs changed
+type RuleCache struct {
+	mu      sync.Mutex
+	Version int                   `json:"version"`
+	Entries map[string]cacheEntry `json:"entries"`
+
+	workspaceOnce sync.Once
+	workspace     map[string]string
+}
+
+type cacheEntry struct {
+	Inputs  map[string]string `json:"inputs"` // path -> content hash
+	Results []RuleResult      `json:"results"`
+}
+
+// LoadRuleCache reads the cache, starting empty when it is missing, corrupt
+// or from another cache version
+func LoadRuleCache() *RuleCache {
+	cache := &RuleCache{Version: cacheVersion, Entries: map[string]cacheEntry{}}
+
+	data, err := os.ReadFile(CacheFilePath)
+	if err != nil {
+		return cache
+	}
+	var stored RuleCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != cacheVersion || stored.Entries == nil {
+		return cache
+	}
+	cache.Entries = stored.Entries
+	return cache
+}
+
+// questGitignore keeps files that only mean something on this machine out of
+// git when the .quest folder is committed for quest ci
+const questGitignore = `# Local to this machine, never commit them: quest ci must not trust the cache
+cache.json
+lock
+.*.tmp-*
+`
+
+// writeQuestGitignore adds a .gitignore for the local files to the quest
+// folder unless it has one
+func writeQuestGitignore() error {
+	path := filepath.Join(QuestDir, ".gitignore")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	return writeFileAtomic(path, []byte(questGitignore), 0644)
+}
+
+// Save writes the cache to .quest
+func (c *RuleCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := writeQuestGitignore(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(CacheFilePath, data, 0644)
+}
+
+// lookup returns the cached results for rule when none of its inputs changed
+func (c *RuleCache) lookup(name string, rule types.Rule, inputs map[string]string) ([]RuleResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.Entries[ruleKey(name, rule)]
+	if !ok || !sameInputs(entry.Inputs, inputs) {
+		return nil, false
+	}
+
+	results := make([]RuleResult, len(entry.Results))
+	for i, r := range entry.Results {
+		r.Cached = true
+		results[i] = r
+	}
+	return results, true
+}
+
+// store remembers the results of rule. Failures of rules that run the
+// learner's code are not kept: a flaky test, a port in use or a slow machine
+// can fail them without any file changing, and a cached failure would stick
+// until one does.
+func (c *RuleCache) store(name string, rule types.Rule, inputs map[string]string, results []RuleResult) {
+	if runsProgram(rule) {
+		for _, r := range results {
+			if !r.Passed {
+				return
+			}
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Entries[ruleKey(name, rule)] = cacheEntry{Inputs: inputs, Results: results}
+}
+
+// runsProgram reports whether rule builds and runs the learner's code
+func runsProgram(rule types.Rule) bool {
+	switch rule.Type {
+	case types.TypeFunctionCases, types.TypeCommand, types.TypeHTTP, types.TypeOpenAPI:
+		return true
+	}
+	return false
+}
+
+// ruleInputs hashes the files a rule reads. Rules that depend on more than
+// files, like git history, are not cacheable.
+func (c *RuleCache) ruleInputs(rule types.Rule) (map[string]string, bool) {
+	inputs := map[string]string{}
+
+	switch rule.Type {
+	case types.TypeExists, types.TypeConfigFile:
+		inputs[rule.Path] = hashPath(rule.Path)
+	case types.TypeGlobCountMin, types.TypeFileContainsAny:
+		matches, err := filepath.Glob(rule.Glob)
+		if err != nil {
+			return nil, false
+		}
+		for _, match := range matches {
+			inputs[match] = hashPath(match)
+		}
+	case types.TypeFunctionCases, types.TypeCommand, types.TypeHTTP, types.TypeOpenAPI:
+		// These build and run the learner's code, so any file can matter
+		for path, hash := range c.workspaceHashes() {
+			inputs[path] = hash
+		}
+		if rule.Golden != "" {
+			if path, err := goldenPath(rule.Golden); err == nil {
+				inputs[path] = hashPath(path)
+			}
+		}
+	default:
+		return nil, false
+	}
+	return inputs, true
+}
+
+// workspaceHashes hashes every file in the workspace once per check
+func (c *RuleCache) workspaceHashes() map[string]string {
+	c.workspaceOnce.Do(func() {
+		c.workspace = map[string]string{}
+		filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				switch info.Name() {
+				case QuestFolderName, ".git", "node_modules":
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Mode().IsRegular() {
+				c.workspace[path] = hashPath(path)
+			}
+			return nil
+		})
+	})
+	return c.workspace
+}
+
+// ruleKey identifies a rule by its reported name and definition, ignoring
+// its last state
+func ruleKey(name string, rule types.Rule) string {
+	rule.LastState = nil
+	data, _ := json.Marshal(rule)
+	sum := sha256.Sum256(append([]byte(name+"\x00"), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashPath returns a content hash for a file, "dir" for a directory and ""
+// when the path does not exist
+func hashPath(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		return "dir"
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func sameInputs(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, hash := range a {
+		if other, ok := b[path]; !ok || other != hash {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package quest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestRuleCache(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	os.MkdirAll(QuestDir, 0755)
+
+	os.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0644)
+	rule := types.Rule{Type: types.TypeFileContainsAny, Name: "Has main", Glob: "*.go", Any: []string{"func main"}}
+
+	evaluate := func(opts CheckOptions) RuleResult {
+		t.Helper()
+		results := EvaluateRule(context.Background(), 0, rule, opts)
+		if len(results) != 1 {
+			t.Fatalf("Expected one result, got %d", len(results))
+		}
+		return results[0]
+	}
+
+	cache := LoadRuleCache()
+	if result := evaluate(CheckOptions{Cache: cache}); result.Cached || !result.Passed {
+		t.Fatalf("First run should evaluate and pass, got %+v", result)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Reloaded from disk, unchanged inputs are a hit
+	cache = LoadRuleCache()
+	if result := evaluate(CheckOptions{Cache: cache}); !result.Cached || !result.Passed {
+		t.Errorf("Expected a cached pass, got %+v", result)
+	}
+	if result := evaluate(CheckOptions{Cache: cache, NoCache: true}); result.Cached {
+		t.Error("Expected --no-cache to re-evaluate")
+	}
+
+	// Changing a matched file invalidates the entry
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+	if result := evaluate(CheckOptions{Cache: cache}); result.Cached || result.Passed {
+		t.Errorf("Expected a fresh failure after the file changed, got %+v", result)
+	}
+
+	// So does a new file matching the glob
+	os.WriteFile("other.go", []byte("package main\n\nfunc main() {}\n"), 0644)
+	if result := evaluate(CheckOptions{Cache: cache}); result.Cached || !result.Passed {
+		t.Errorf("Expected a fresh pass after a new file matched, got %+v", result)
+	}
+
+	if _, ok := cache.ruleInputs(types.Rule{Type: types.TypeGitClean}); ok {
+		t.Error("Expected git rules not to be cacheable")
+	}
+
+	// Failures of rules that run code are never cached, passes are
+	command := types.Rule{Type: types.TypeCommand, Name: "Runs"}
+	inputs, _ := cache.ruleInputs(command)
+	cache.store("Runs", command, inputs, []RuleResult{{Name: "Runs", Reason: "exit status 1"}})
+	if _, ok := cache.lookup("Runs", command, inputs); ok {
+		t.Error("Expected a failed command not to be cached")
+	}
+	cache.store("Runs", command, inputs, []RuleResult{{Name: "Runs", Passed: true}})
+	if _, ok := cache.lookup("Runs", command, inputs); !ok {
+		t.Error("Expected a passing command to be cached")
+	}
+}
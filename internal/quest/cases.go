@@ -0,0 +1,356 @@
+package quest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+// HarnessFileName is the name the generated test harness takes in the
+// learner's package, through a go build overlay
+const HarnessFileName = "quest_harness_test.go"
+
+const caseLinePrefix = "QUEST_CASE\t"
+
+// CaseResult is the outcome of a single function_cases test case
+type CaseResult struct {
+	Name    string
+	Call    string
+	Passed  bool
+	Message string
+}
+
+var harnessTemplate = template.Must(template.New("harness").Parse(`// Code generated by quest. DO NOT EDIT.
+// go test sees it through an overlay, it is never written to your package.
+
+package {{.Package}}
+
+import (
+	questjson "encoding/json"
+	questfmt "fmt"
+	questreflect "reflect"
+	queststrings "strings"
+	questtesting "testing"
+)
+
+const questCasesJSON = {{.CasesJSON}}
+
+type questCase struct {
+	Args          []questjson.RawMessage ` + "`json:\"args\"`" + `
+	Expected      questjson.RawMessage   ` + "`json:\"expected\"`" + `
+	ExpectedError string                 ` + "`json:\"expectedError\"`" + `
+	WantError     bool                   ` + "`json:\"wantError\"`" + `
+}
+
+func TestQuestHarness(t *questtesting.T) {
+	var cases []questCase
+	if err := questjson.Unmarshal([]byte(questCasesJSON), &cases); err != nil {
+		t.Fatalf("quest: invalid cases: %v", err)
+	}
+	fn := questreflect.ValueOf({{.Function}})
+	for i, c := range cases {
+		ok, msg := questRunCase(fn, c)
+		if ok {
+			questfmt.Printf("QUEST_CASE\t%d\tpass\n", i)
+		} else {
+			questfmt.Printf("QUEST_CASE\t%d\tfail\t%s\n", i, queststrings.ReplaceAll(msg, "\n", " "))
+		}
+	}
+}
+
+func questRunCase(fn questreflect.Value, c questCase) (ok bool, msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok, msg = false, questfmt.Sprintf("panicked: %v", r)
+		}
+	}()
+
+	ft := fn.Type()
+	if ft.Kind() != questreflect.Func {
+		return false, "{{.Function}} is not a function"
+	}
+	if ft.NumIn() != len(c.Args) {
+		return false, questfmt.Sprintf("{{.Function}} takes %d argument(s), case has %d", ft.NumIn(), len(c.Args))
+	}
+
+	in := make([]questreflect.Value, len(c.Args))
+	for i, raw := range c.Args {
+		v := questreflect.New(ft.In(i))
+		if err := questjson.Unmarshal(raw, v.Interface()); err != nil {
+			return false, questfmt.Sprintf("argument %d: %v", i+1, err)
+		}
+		in[i] = v.Elem()
+	}
+
+	var out []questreflect.Value
+	if ft.IsVariadic() {
+		out = fn.CallSlice(in)
+	} else {
+		out = fn.Call(in)
+	}
+
+	var callErr error
+	errType := questreflect.TypeOf((*error)(nil)).Elem()
+	if n := len(out); n > 0 && ft.Out(n-1) == errType {
+		if !out[n-1].IsNil() {
+			callErr = out[n-1].Interface().(error)
+		}
+		out = out[:n-1]
+	}
+
+	if c.WantError || c.ExpectedError != "" {
+		if callErr == nil {
+			return false, "expected an error, got none"
+		}
+		if !queststrings.Contains(callErr.Error(), c.ExpectedError) {
+			return false, questfmt.Sprintf("expected error containing %q, got %q", c.ExpectedError, callErr.Error())
+		}
+		return true, ""
+	}
+	if callErr != nil {
+		return false, "unexpected error: " + callErr.Error()
+	}
+	if len(c.Expected) == 0 {
+		return true, ""
+	}
+
+	var got interface{}
+	if len(out) == 1 {
+		got = out[0].Interface()
+	} else {
+		values := make([]interface{}, len(out))
+		for i, v := range out {
+			values[i] = v.Interface()
+		}
+		got = values
+	}
+	gotJSON, err := questjson.Marshal(got)
+	if err != nil {
+		return false, questfmt.Sprintf("result is not JSON encodable: %v", err)
+	}
+
+	var want, have interface{}
+	if err := questjson.Unmarshal(c.Expected, &want); err != nil {
+		return false, questfmt.Sprintf("invalid expected value: %v", err)
+	}
+	if err := questjson.Unmarshal(gotJSON, &have); err != nil {
+		return false, questfmt.Sprintf("result is not JSON encodable: %v", err)
+	}
+	if !questreflect.DeepEqual(want, have) {
+		return false, questfmt.Sprintf("expected %s, got %s", string(c.Expected), string(gotJSON))
+	}
+	return true, ""
+}
+`))
+
+// runFunctionCases generates a test harness for rule.Function in the package
+// at rule.Path, runs it with go test in a sandbox and returns one result per
+// case.
+func runFunctionCases(ctx context.Context, rule types.Rule, workspace *workspaceCopy) ([]CaseResult, error) {
+	if rule.Function == "" {
+		return nil, fmt.Errorf("function_cases rule is missing 'function'")
+	}
+	if len(rule.Cases) == 0 {
+		return nil, fmt.Errorf("function_cases rule for %s has no cases", rule.Function)
+	}
+
+	dir := rule.Path
+	if dir == "" {
+		dir = "."
+	}
+
+	pkg, err := packageName(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	harness, err := renderHarness(pkg, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := workspaceRelative(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sb, err := newSandbox(workspace)
+	if err != nil {
+		return nil, err
+	}
+	defer sb.Close()
+
+	// The workspace copy may be shared with other rules testing the same
+	// package, so the harness is added through an overlay instead of written
+	// next to the learner's code
+	pkgDir := filepath.Join(sb.Dir, rel)
+	overlay, err := writeHarnessOverlay(sb, filepath.Join(pkgDir, HarnessFileName), harness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write test harness: %w", err)
+	}
+
+	cmd, err := sb.Command("go", "test", "-overlay", overlay, "-count=1", "-v", "-run", "^TestQuestHarness$", ".")
+	if err != nil {
+		return nil, err
+	}
+	cmd.Dir = pkgDir
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := sb.Run(ctx, cmd); err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return parseCaseOutput(rule, output.Bytes())
+}
+
+// writeHarnessOverlay stores the harness in the sandbox's own temporary
+// folder and returns a go build overlay that places it at path
+func writeHarnessOverlay(sb *sandbox, path string, harness []byte) (string, error) {
+	harnessPath := filepath.Join(sb.root, "tmp", HarnessFileName)
+	if err := os.WriteFile(harnessPath, harness, 0644); err != nil {
+		return "", err
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {path: harnessPath}})
+	if err != nil {
+		return "", err
+	}
+	overlayPath := filepath.Join(sb.root, "overlay.json")
+	return overlayPath, os.WriteFile(overlayPath, overlay, 0644)
+}
+
+// workspaceRelative turns a rule path into one relative to the workspace
+func workspaceRelative(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Clean(path)
+	} else {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		if path, err = filepath.Rel(wd, path); err != nil {
+			return "", err
+		}
+	}
+	if path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path '%s' is outside the workspace", path)
+	}
+	return path, nil
+}
+
+// packageName reads the package clause of the first non-test Go file in dir
+func packageName(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	for _, match := range matches {
+		if strings.HasSuffix(match, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), match, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", match, err)
+		}
+		return file.Name.Name, nil
+	}
+	return "", fmt.Errorf("no Go files found in '%s'", dir)
+}
+
+func renderHarness(pkg string, rule types.Rule) ([]byte, error) {
+	casesJSON, err := json.Marshal(rule.Cases)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cases for %s: %w", rule.Function, err)
+	}
+
+	var buf bytes.Buffer
+	err = harnessTemplate.Execute(&buf, struct {
+		Package   string
+		Function  string
+		CasesJSON string
+	}{
+		Package:   pkg,
+		Function:  rule.Function,
+		CasesJSON: strconv.Quote(string(casesJSON)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseCaseOutput maps the QUEST_CASE lines printed by the harness back onto
+// the rule's cases. Without any such line the package failed to build.
+func parseCaseOutput(rule types.Rule, output []byte) ([]CaseResult, error) {
+	results := make([]CaseResult, len(rule.Cases))
+	for i, c := range rule.Cases {
+		results[i] = CaseResult{
+			Name:    c.Name,
+			Call:    formatCall(c.Args),
+			Message: "case did not run",
+		}
+		if results[i].Name == "" {
+			results[i].Name = fmt.Sprintf("case %d", i+1)
+		}
+	}
+
+	found := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, caseLinePrefix) {
+			continue
+		}
+		fields := strings.SplitN(strings.TrimPrefix(line, caseLinePrefix), "\t", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		idx, err := strconv.Atoi(fields[0])
+		if err != nil || idx < 0 || idx >= len(results) {
+			continue
+		}
+		found = true
+		results[idx].Passed = fields[1] == "pass"
+		results[idx].Message = ""
+		if len(fields) == 3 {
+			results[idx].Message = fields[2]
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("could not run cases for %s: %s", rule.Function, firstLines(string(output), 5))
+	}
+	return results, nil
+}
+
+func formatCall(args []json.RawMessage) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, arg); err != nil {
+			parts[i] = string(arg)
+			continue
+		}
+		parts[i] = buf.String()
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func firstLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return strings.Join(lines, "; ")
+}
@@ -0,0 +1,177 @@
+package quest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func writeCasesModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/calc\n\ngo 1.18\n",
+		"calc.go": `package calc
+
+import "errors"
+
+func Add(a, b int) int { return a + b }
+
+func Divide(a, b float64) (float64, error) {
+	if b == 0 {
+		return 0, errors.New("division by zero")
+	}
+	return a / b, nil
+}
+
+func Words(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	// Rules run against a sandboxed copy of the working directory
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func rawArgs(args ...string) []json.RawMessage {
+	raw := make([]json.RawMessage, len(args))
+	for i, arg := range args {
+		raw[i] = json.RawMessage(arg)
+	}
+	return raw
+}
+
+func TestRunFunctionCases(t *testing.T) {
+	dir := writeCasesModule(t)
+
+	tests := []struct {
+		name     string
+		rule     types.Rule
+		expected []bool
+	}{
+		{
+			name: "expected values",
+			rule: types.Rule{
+				Function: "Add",
+				Cases: []types.TestCase{
+					{Name: "small", Args: rawArgs("1", "2"), Expected: json.RawMessage("3")},
+					{Name: "wrong", Args: rawArgs("1", "2"), Expected: json.RawMessage("4")},
+				},
+			},
+			expected: []bool{true, false},
+		},
+		{
+			name: "expected errors",
+			rule: types.Rule{
+				Function: "Divide",
+				Cases: []types.TestCase{
+					{Args: rawArgs("1", "0"), ExpectedError: "division by zero"},
+					{Args: rawArgs("6", "3"), Expected: json.RawMessage("2")},
+					{Args: rawArgs("6", "3"), WantError: true},
+				},
+			},
+			expected: []bool{true, true, false},
+		},
+		{
+			name: "argument mismatch",
+			rule: types.Rule{
+				Function: "Words",
+				Cases: []types.TestCase{
+					{Args: rawArgs(`"go"`), Expected: json.RawMessage(`["go"]`)},
+					{Args: rawArgs(`"a"`, `"b"`)},
+				},
+			},
+			expected: []bool{true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Type = types.TypeFunctionCases
+			tt.rule.Path = dir
+
+			results, err := runFunctionCases(context.Background(), tt.rule, nil)
+			if err != nil {
+				t.Fatalf("runFunctionCases() error = %v", err)
+			}
+			if len(results) != len(tt.expected) {
+				t.Fatalf("Expected %d results, got %d", len(tt.expected), len(results))
+			}
+			for i, result := range results {
+				if result.Passed != tt.expected[i] {
+					t.Errorf("case %d (%s): expected passed=%v, got %v (%s)", i, result.Name, tt.expected[i], result.Passed, result.Message)
+				}
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, HarnessFileName)); !os.IsNotExist(err) {
+		t.Error("Expected harness file to be removed")
+	}
+}
+
+func TestRunFunctionCasesBuildFailure(t *testing.T) {
+	dir := writeCasesModule(t)
+
+	rule := types.Rule{
+		Type:     types.TypeFunctionCases,
+		Path:     dir,
+		Function: "Missing",
+		Cases:    []types.TestCase{{Args: rawArgs("1")}},
+	}
+
+	if _, err := runFunctionCases(context.Background(), rule, nil); err == nil {
+		t.Error("Expected error when the function does not exist")
+	}
+}
+
+func TestRunFunctionCasesSharedWorkspace(t *testing.T) {
+	dir := writeCasesModule(t)
+
+	rules := []types.Rule{
+		{Type: types.TypeFunctionCases, Name: "Add", Path: dir, Function: "Add",
+			Cases: []types.TestCase{{Args: rawArgs("1", "2"), Expected: json.RawMessage("3")}}},
+		{Type: types.TypeFunctionCases, Name: "Words", Path: dir, Function: "Words",
+			Cases: []types.TestCase{{Args: rawArgs(`"go"`), Expected: json.RawMessage(`["go"]`)}}},
+	}
+
+	// Both rules test the same package at once in one copy of the workspace
+	workspace := &workspaceCopy{}
+	opts := CheckOptions{Jobs: 2, Workspace: workspace}
+	EvaluateRules(context.Background(), rules, opts, func(index int, results []RuleResult) {
+		for _, r := range results {
+			if !r.Passed {
+				t.Errorf("Expected %s to pass, got %s", r.Name, r.Reason)
+			}
+		}
+	})
+	copied, err := workspace.dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(copied, HarnessFileName)); !os.IsNotExist(err) {
+		t.Error("Expected the harness to stay out of the shared copy")
+	}
+	workspace.Close()
+	if _, err := os.Stat(copied); !os.IsNotExist(err) {
+		t.Error("Expected the shared copy to be removed on Close")
+	}
+}
@@ -1,15 +1,24 @@
 package quest
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/jovanpet/quest/internal/types"
 )
 
 func CheckRule(rule types.Rule) (bool, error) {
+	return checkRule(context.Background(), rule, CheckOptions{})
+}
+
+func checkRule(ctx context.Context, rule types.Rule, opts CheckOptions) (bool, error) {
 	switch rule.Type {
 	case types.TypeExists:
 		exists, err := checkExistenceOfFile(rule.Path)
@@ -36,10 +45,335 @@ func CheckRule(rule types.Rule) (bool, error) {
 			return false, fmt.Errorf("%s doesn't contain any of: %v", rule.Glob, rule.Any)
 		}
 		return contains, err
+	case types.TypeFunctionCases:
+		cases, err := runFunctionCases(ctx, rule, opts.Workspace)
+		if err != nil {
+			return false, err
+		}
+		failed := 0
+		for _, c := range cases {
+			if !c.Passed {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return false, fmt.Errorf("%d of %d case(s) failed for %s", failed, len(cases), rule.Function)
+		}
+		return true, nil
+	case types.TypeConfigFile:
+		return checkConfigFile(rule)
+	case types.TypeOpenAPI:
+		operations, err := runOpenAPIContract(ctx, rule, opts.Workspace)
+		if err != nil {
+			return false, err
+		}
+		var mismatched []string
+		for _, op := range operations {
+			if !op.Passed {
+				mismatched = append(mismatched, op.Name())
+			}
+		}
+		if len(mismatched) > 0 {
+			return false, fmt.Errorf("%d operation(s) do not match the contract: %s", len(mismatched), strings.Join(mismatched, ", "))
+		}
+		return true, nil
+	case types.TypeCommand, types.TypeHTTP:
+		passed, reason, _ := evaluateOutputRule(ctx, rule, opts)
+		if !passed {
+			return false, fmt.Errorf("%s", reason)
+		}
+		return true, nil
+	case types.TypeGitCommits, types.TypeGitClean, types.TypeGitBranch, types.TypeGitMessage:
+		return checkGitRule(rule, opts)
 	}
 	return false, fmt.Errorf("The Type setting is invalid.")
 }
 
+// DefaultRuleTimeout bounds a rule that sets no timeout of its own
+const DefaultRuleTimeout = 2 * time.Minute
+
+// CheckOptions tweaks how rules are evaluated during a check
+type CheckOptions struct {
+	UpdateGoldens bool          // rewrite golden files from the current output
+	Jobs          int           // rules evaluated at once, defaults to the number of CPUs
+	RuleTimeout   time.Duration // for rules without a timeout, defaults to DefaultRuleTimeout
+
+	// Cache reuses results of rules whose inputs did not change; NoCache
+	// re-evaluates every rule but still refreshes the cache
+	Cache   *RuleCache
+	NoCache bool
+
+	// Where the current task started, for git rules
+	TaskStartedAt   time.Time
+	TaskStartCommit string
+
+	// Workspace is the copy of the workspace the rules that run code share.
+	// Without one each of them copies the workspace for itself.
+	Workspace *workspaceCopy
+}
+
+// RuleResult is one reported check line. Most rules produce a single result,
+// function_cases rules produce one per declared case and openapi rules one
+// per documented operation.
+type RuleResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason,omitempty"`
+	Detail string `json:"detail,omitempty"` // optional multi-line output, such as a golden diff
+	File   string `json:"file,omitempty"`   // evidence: the file the rule looked at, when there is one
+	Line   int    `json:"line,omitempty"`   // and the 1-based line that matched
+	Cached bool   `json:"-"`                // reused from .quest/cache.json
+
+	// Updated is set instead of Passed when check --update-goldens rewrote
+	// the rule's golden
+	Updated bool `json:"updated,omitempty"`
+}
+
+// EvaluateRules runs rules on a bounded pool of workers and calls report with
+// each rule's results in declaration order as soon as they are available.
+// Cancelling ctx stops every running rule.
+func EvaluateRules(ctx context.Context, rules []types.Rule, opts CheckOptions, report func(index int, results []RuleResult)) {
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+
+	// Rules are copied so the caller can update its slice while we run
+	rules = append([]types.Rule(nil), rules...)
+	done := make([]chan []RuleResult, len(rules))
+	for i := range done {
+		done[i] = make(chan []RuleResult, 1)
+	}
+
+	go func() {
+		workers := make(chan struct{}, jobs)
+		for i, rule := range rules {
+			workers <- struct{}{}
+			go func(i int, rule types.Rule) {
+				defer func() { <-workers }()
+				done[i] <- EvaluateRule(ctx, i, rule, opts)
+			}(i, rule)
+		}
+	}()
+
+	for i := range rules {
+		report(i, <-done[i])
+	}
+}
+
+// EvaluateRule runs a rule under its timeout and returns the lines to report
+// for it.
+func EvaluateRule(ctx context.Context, index int, rule types.Rule, opts CheckOptions) []RuleResult {
+	ruleName := rule.Name
+	if ruleName == "" {
+		ruleName = fmt.Sprintf("Rule %d", index+1)
+	}
+
+	timeout := opts.RuleTimeout
+	if timeout <= 0 {
+		timeout = DefaultRuleTimeout
+	}
+	if rule.Timeout != "" {
+		d, err := time.ParseDuration(rule.Timeout)
+		if err != nil || d <= 0 {
+			return []RuleResult{{Name: ruleName, Reason: fmt.Sprintf("invalid timeout '%s'", rule.Timeout)}}
+		}
+		timeout = d
+	}
+
+	if ctx.Err() != nil {
+		return []RuleResult{{Name: ruleName, Reason: interruptedReason(ctx, nil, timeout)}}
+	}
+
+	var inputs map[string]string
+	cacheable := false
+	if opts.Cache != nil && !opts.UpdateGoldens {
+		inputs, cacheable = opts.Cache.ruleInputs(rule)
+		if cacheable && !opts.NoCache {
+			if results, ok := opts.Cache.lookup(ruleName, rule, inputs); ok {
+				return results
+			}
+		}
+	}
+
+	ruleCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := evaluateRule(ruleCtx, ruleName, rule, opts)
+	if ruleCtx.Err() != nil {
+		return []RuleResult{{Name: ruleName, Reason: interruptedReason(ctx, ruleCtx, timeout)}}
+	}
+	if cacheable {
+		opts.Cache.store(ruleName, rule, inputs, results)
+	}
+	return results
+}
+
+// checkDuration is the longest checking tasks can take: the timeout of the
+// whole check when there is one, or else every rule running into its own
+// timeout one after the other
+func checkDuration(timeout time.Duration, tasks []types.Task, opts CheckOptions) time.Duration {
+	if timeout > 0 {
+		return timeout
+	}
+	var total time.Duration
+	for _, task := range tasks {
+		for _, rule := range task.Validation.Rules {
+			d := opts.RuleTimeout
+			if d <= 0 {
+				d = DefaultRuleTimeout
+			}
+			if own, err := time.ParseDuration(rule.Timeout); err == nil && own > 0 {
+				d = own
+			}
+			total += d
+		}
+	}
+	return total
+}
+
+// interruptedReason explains why a rule was stopped before it finished
+func interruptedReason(ctx, ruleCtx context.Context, timeout time.Duration) string {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return "cancelled"
+	case ctx.Err() != nil:
+		return "check timed out before this rule finished"
+	case ruleCtx != nil && ruleCtx.Err() != nil:
+		return fmt.Sprintf("timed out after %s", timeout)
+	}
+	return ""
+}
+
+func evaluateRule(ctx context.Context, ruleName string, rule types.Rule, opts CheckOptions) []RuleResult {
+	if rule.Type == types.TypeCommand || rule.Type == types.TypeHTTP {
+		passed, reason, detail := evaluateOutputRule(ctx, rule, opts)
+		if passed && opts.UpdateGoldens && rule.Golden != "" {
+			return []RuleResult{{Name: ruleName, Updated: true, Reason: reason}}
+		}
+		return []RuleResult{{Name: ruleName, Passed: passed, Reason: reason, Detail: detail}}
+	}
+
+	if rule.Type == types.TypeFunctionCases {
+		cases, err := runFunctionCases(ctx, rule, opts.Workspace)
+		if err != nil {
+			return []RuleResult{{Name: ruleName, Reason: err.Error()}}
+		}
+		results := make([]RuleResult, 0, len(cases))
+		for _, c := range cases {
+			reason := c.Message
+			if c.Passed {
+				reason = fmt.Sprintf("- %s%s", rule.Function, c.Call)
+			}
+			results = append(results, RuleResult{
+				Name:   fmt.Sprintf("%s: %s", ruleName, c.Name),
+				Passed: c.Passed,
+				Reason: reason,
+			})
+		}
+		return results
+	}
+
+	if rule.Type == types.TypeOpenAPI {
+		operations, err := runOpenAPIContract(ctx, rule, opts.Workspace)
+		if err != nil {
+			return []RuleResult{{Name: ruleName, Reason: err.Error()}}
+		}
+		results := make([]RuleResult, 0, len(operations))
+		for _, op := range operations {
+			results = append(results, RuleResult{
+				Name:   fmt.Sprintf("%s: %s", ruleName, op.Name()),
+				Passed: op.Passed,
+				Reason: op.Message,
+			})
+		}
+		return results
+	}
+
+	check, err := checkRule(ctx, rule, opts)
+	if !check {
+		// Show the failure reason on the same line
+		failureReason := ""
+		if err != nil {
+			failureReason = err.Error()
+		} else if rule.Description != "" {
+			failureReason = rule.Description
+		}
+		file, line := ruleEvidence(rule, false)
+		return []RuleResult{{Name: ruleName, Reason: failureReason, File: file, Line: line}}
+	}
+
+	file, line := ruleEvidence(rule, true)
+	return []RuleResult{{Name: ruleName, Passed: true, Reason: successReason(rule, opts), File: file, Line: line}}
+}
+
+// ruleEvidence names the file a rule is about and, for a passing
+// file_contains_any rule, the line that matched
+func ruleEvidence(rule types.Rule, passed bool) (string, int) {
+	switch rule.Type {
+	case types.TypeExists, types.TypeConfigFile:
+		return filepath.ToSlash(rule.Path), 0
+	case types.TypeFileContainsAny:
+		matches, err := getFilePathsBasedOnRegex(rule.Glob)
+		if err != nil || len(matches) == 0 {
+			return "", 0
+		}
+		if passed {
+			if file, line, ok := snippetLocation(matches, rule.Any); ok {
+				return filepath.ToSlash(file), line
+			}
+		}
+		if len(matches) == 1 {
+			return filepath.ToSlash(matches[0]), 0
+		}
+	}
+	return "", 0
+}
+
+// snippetLocation finds the first file and line matching any of patterns
+func snippetLocation(files []string, patterns []string) (string, int, bool) {
+	for _, filePath := range files {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			continue
+		}
+		for _, pattern := range patterns {
+			regex, err := regexp.Compile(pattern)
+			if err != nil {
+				return "", 0, false
+			}
+			if loc := regex.FindIndex(content); loc != nil {
+				return filePath, 1 + strings.Count(string(content[:loc[0]]), "\n"), true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// successReason builds the success message based on rule type
+func successReason(rule types.Rule, opts CheckOptions) string {
+	switch rule.Type {
+	case types.TypeExists:
+		return fmt.Sprintf("- found '%s'", rule.Path)
+	case types.TypeGlobCountMin:
+		count, _ := CountFilesMatching(rule.Glob)
+		return fmt.Sprintf("- found %d file(s) matching '%s'", count, rule.Glob)
+	case types.TypeFileContainsAny:
+		if len(rule.Any) == 1 {
+			return fmt.Sprintf("- %s - contains '%s'", rule.Glob, rule.Any[0])
+		}
+		return fmt.Sprintf("- %s - contains required '%v'", rule.Glob, rule.Any)
+	case types.TypeConfigFile:
+		if len(rule.Schema) > 0 {
+			return fmt.Sprintf("- %s matches the schema", rule.Path)
+		}
+		return fmt.Sprintf("- %s has %d expected key(s)", rule.Path, len(rule.Keys))
+	case types.TypeGitCommits, types.TypeGitClean, types.TypeGitBranch, types.TypeGitMessage:
+		return gitSuccessReason(rule, opts)
+	}
+	return ""
+}
+
 func checkExistenceOfFile(filePath string) (bool, error) {
 	_, err := os.Stat(filePath)
 	if err == nil {
@@ -94,6 +428,9 @@ func checkFileContainsSpecificSnippets(globPattern string, patterns []string) (b
 	return false, nil
 }
 
+// artifactStub is what quest next puts in the files a task starts with
+const artifactStub = "// TODO: implement\n"
+
 func createArtifact(artifactPath string) error {
 	// Skip if file already exists
 	if _, err := os.Stat(artifactPath); err == nil {
@@ -109,5 +446,5 @@ func createArtifact(artifactPath string) error {
 	}
 
 	// Create the file
-	return os.WriteFile(artifactPath, []byte("// TODO: implement\n"), 0644)
+	return os.WriteFile(artifactPath, []byte(artifactStub), 0644)
 }
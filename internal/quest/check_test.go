@@ -1,9 +1,12 @@
 package quest
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jovanpet/quest/internal/types"
 )
@@ -389,3 +392,64 @@ func TestCreateArtifact(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateRulesOrderAndTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	existing := filepath.Join(tmpDir, "main.go")
+	os.WriteFile(existing, []byte("package main\n"), 0644)
+
+	rules := []types.Rule{
+		{Type: types.TypeCommand, Name: "slow", Command: "sh -c", Args: []string{"sleep 0.3; echo done"}},
+		{Type: types.TypeExists, Name: "fast", Path: existing},
+		{Type: types.TypeCommand, Name: "hangs", Command: "sleep 30", Timeout: "200ms"},
+		{Type: types.TypeExists, Name: "bad timeout", Path: existing, Timeout: "soon"},
+	}
+
+	start := time.Now()
+	var names []string
+	var reasons []string
+	EvaluateRules(context.Background(), rules, CheckOptions{Jobs: 4}, func(i int, results []RuleResult) {
+		for _, r := range results {
+			names = append(names, r.Name)
+			reasons = append(reasons, r.Reason)
+		}
+	})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the hanging rule to be stopped, took %s", elapsed)
+	}
+	if strings.Join(names, ",") != "slow,fast,hangs,bad timeout" {
+		t.Errorf("Results not reported in declaration order: %v", names)
+	}
+	if reasons[2] != "timed out after 200ms" {
+		t.Errorf("Unexpected timeout reason %q", reasons[2])
+	}
+	if !strings.Contains(reasons[3], "invalid timeout") {
+		t.Errorf("Unexpected reason for an invalid timeout %q", reasons[3])
+	}
+}
+
+func TestEvaluateRulesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	rules := []types.Rule{
+		{Type: types.TypeCommand, Name: "hangs", Command: "sleep 30"},
+		{Type: types.TypeCommand, Name: "queued", Command: "sleep 30"},
+	}
+
+	start := time.Now()
+	var reasons []string
+	EvaluateRules(ctx, rules, CheckOptions{Jobs: 1}, func(i int, results []RuleResult) {
+		reasons = append(reasons, results[0].Reason)
+	})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected cancellation to stop the check, took %s", elapsed)
+	}
+	for i, reason := range reasons {
+		if reason != "cancelled" {
+			t.Errorf("Rule %d: expected 'cancelled', got %q", i+1, reason)
+		}
+	}
+}
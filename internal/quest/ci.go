@@ -0,0 +1,94 @@
+package quest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// RunCI checks every task in the plan without prompting or saving anything.
+// It fails when a task recorded as completed no longer passes, or with
+// --require-all when any task fails.
+func RunCI(cmd *cobra.Command, args []string) error {
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		format.ErrorWithTip("Failed to load quest data", err, "Commit the .quest folder so CI can see your progress")
+		return commandError(err)
+	}
+	requireAll, _ := cmd.Flags().GetBool("require-all")
+
+	format.Header("Quest CI")
+
+	// Never trust cached results here: the cache may have been committed
+	// along with .quest, or written by hand to make the build pass
+	opts := checkOptions(cmd, state)
+	defer opts.Workspace.Close()
+	opts.Cache = nil
+	opts.NoCache = true
+	ctx, cancel := checkContext(cmd)
+	defer cancel()
+
+	taskIndex := -1
+	passedTasks := 0
+	var failedRequired, failedPending []string
+
+	for chIdx, chapter := range plan.Chapters {
+		format.SectionHeader(fmt.Sprintf("Chapter %d: %s", chIdx+1, chapter.Title))
+
+		for _, quest := range chapter.Quests {
+			for _, task := range quest.Tasks {
+				taskIndex++
+				current := state.QuestStarted && taskIndex == state.CurrentTaskIndex
+
+				format.CheckHeader(taskIndex+1, task.Title)
+				_, failedCount, _ := checkTask(ctx, &task, opts, current)
+				if errors.Is(ctx.Err(), context.Canceled) {
+					format.Newline()
+					format.Warning("CI check cancelled")
+					return exitWith(ExitFail, errCancelled)
+				}
+
+				label := fmt.Sprintf("Task %d: %s", taskIndex+1, task.Title)
+				switch {
+				case failedCount == 0:
+					passedTasks++
+				case ciRequired(state, task, requireAll):
+					failedRequired = append(failedRequired, label)
+				default:
+					failedPending = append(failedPending, label)
+				}
+			}
+		}
+	}
+
+	format.Divider()
+	format.Line(fmt.Sprintf("%d of %d tasks pass, %d recorded as completed",
+		passedTasks, taskIndex+1, len(state.CompletedTaskIDs)))
+	format.Newline()
+
+	if len(failedPending) > 0 {
+		format.Dim(fmt.Sprintf("%d task(s) not completed yet, they don't fail the build", len(failedPending)))
+		format.Newline()
+	}
+	if len(failedRequired) > 0 {
+		if requireAll {
+			format.Error("These tasks fail", nil)
+		} else {
+			format.Error("These completed tasks fail now", nil)
+		}
+		format.List(failedRequired)
+		return exitWith(ExitFail, errChecksFailed)
+	}
+
+	format.Success("Quest CI passed")
+	return nil
+}
+
+// ciRequired reports whether a failing task fails the build
+func ciRequired(state *types.State, task types.Task, requireAll bool) bool {
+	return requireAll || contains(state.CompletedTaskIDs, task.ID)
+}
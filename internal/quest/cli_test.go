@@ -0,0 +1,18 @@
+package quest_test
+
+import (
+	"github.com/jovanpet/quest/cmd"
+	"github.com/jovanpet/quest/internal/quest"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	quest.CLICommand = func(name string) *cobra.Command {
+		for _, c := range cmd.Root().Commands() {
+			if c.Name() == name {
+				return c
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package quest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// CLICommand finds 'quest <name>' as cmd/ builds it. cmd imports this
+// package, so only the external test package can reach it and sets this in
+// cli_test.go.
+var CLICommand func(name string) *cobra.Command
+
+// testCommand returns 'quest <name>' from cmd/ with its flags, including the
+// persistent ones of the root command, back at their defaults and args
+// parsed into them
+func testCommand(t *testing.T, name string, args ...string) *cobra.Command {
+	t.Helper()
+	cmd := CLICommand(name)
+	if cmd == nil {
+		t.Fatalf("No 'quest %s' command in cmd/", name)
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		resetFlags(c.PersistentFlags())
+	}
+	resetFlags(cmd.Flags())
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("Invalid flags for 'quest %s': %v", name, err)
+	}
+	return cmd
+}
+
+// resetFlags undoes what an earlier test parsed into flags
+func resetFlags(flags *pflag.FlagSet) {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			var values []string
+			if def := strings.Trim(f.DefValue, "[]"); def != "" {
+				values = strings.Split(def, ",")
+			}
+			slice.Replace(values)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+}
@@ -0,0 +1,93 @@
+package quest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/jovanpet/quest/internal/configfile"
+	"github.com/jovanpet/quest/internal/schema"
+	"github.com/jovanpet/quest/internal/types"
+)
+
+// maxReportedProblems caps how many config problems end up in one check line
+const maxReportedProblems = 3
+
+func checkConfigFile(rule types.Rule) (bool, error) {
+	value, err := configfile.ParseFile(rule.Path, configfile.Format(rule.Format))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, fmt.Errorf("file '%s' does not exist", rule.Path)
+		}
+		var parseErr *configfile.ParseError
+		if errors.As(err, &parseErr) {
+			return false, fmt.Errorf("%s: parse error at %s", rule.Path, parseErr.Error())
+		}
+		return false, fmt.Errorf("%s: %w", rule.Path, err)
+	}
+
+	var problems []string
+	for _, assertion := range rule.Keys {
+		problems = append(problems, checkKeyAssertion(value, assertion)...)
+	}
+
+	if len(rule.Schema) > 0 {
+		s, err := schema.Parse(rule.Schema)
+		if err != nil {
+			return false, err
+		}
+		problems = append(problems, schema.Validate(value, s)...)
+	}
+
+	if len(problems) == 0 {
+		return true, nil
+	}
+
+	if len(problems) > maxReportedProblems {
+		extra := len(problems) - maxReportedProblems
+		problems = append(problems[:maxReportedProblems], fmt.Sprintf("and %d more", extra))
+	}
+	return false, fmt.Errorf("%s: %s", rule.Path, strings.Join(problems, "; "))
+}
+
+func checkKeyAssertion(value interface{}, assertion types.KeyAssertion) []string {
+	actual, found, err := configfile.Lookup(value, assertion.Key)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if !found {
+		return []string{fmt.Sprintf("missing key '%s'", assertion.Key)}
+	}
+
+	var problems []string
+	if assertion.Type != "" {
+		for _, msg := range schema.Validate(actual, map[string]interface{}{"type": assertion.Type}) {
+			problems = append(problems, strings.Replace(msg, "$", assertion.Key, 1))
+		}
+	}
+
+	if len(assertion.Equals) > 0 {
+		var expected interface{}
+		if err := json.Unmarshal(assertion.Equals, &expected); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid 'equals' for '%s': %v", assertion.Key, err))
+		} else if !reflect.DeepEqual(expected, actual) {
+			got, _ := json.Marshal(actual)
+			problems = append(problems, fmt.Sprintf("%s is %s, expected %s", assertion.Key, got, strings.TrimSpace(string(assertion.Equals))))
+		}
+	}
+
+	if assertion.Matches != "" {
+		regex, err := regexp.Compile(assertion.Matches)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("invalid 'matches' for '%s': %v", assertion.Key, err))
+		} else if !regex.MatchString(fmt.Sprint(actual)) {
+			problems = append(problems, fmt.Sprintf("%s = %v does not match '%s'", assertion.Key, actual, assertion.Matches))
+		}
+	}
+
+	return problems
+}
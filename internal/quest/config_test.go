@@ -0,0 +1,102 @@
+package quest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestCheckConfigFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"config.yaml":  "server:\n  port: 8080\n  host: localhost\nlog_level: debug\n",
+		".env.example": "DATABASE_URL=postgres://localhost/app\nPORT=8080\n",
+		"broken.yaml":  "server:\n  port: 8080\n   host: localhost\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		rule        types.Rule
+		expected    bool
+		errContains string
+	}{
+		{
+			name: "keys with types and values",
+			rule: types.Rule{
+				Path: "config.yaml",
+				Keys: []types.KeyAssertion{
+					{Key: "server.port", Type: "integer", Equals: json.RawMessage("8080")},
+					{Key: "log_level", Matches: "^(debug|info)$"},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "missing key",
+			rule: types.Rule{
+				Path: "config.yaml",
+				Keys: []types.KeyAssertion{{Key: "server.timeout"}},
+			},
+			errContains: "missing key 'server.timeout'",
+		},
+		{
+			name: "wrong type",
+			rule: types.Rule{
+				Path: "config.yaml",
+				Keys: []types.KeyAssertion{{Key: "server.host", Type: "number"}},
+			},
+			errContains: "server.host: expected number, got string",
+		},
+		{
+			name: "env file",
+			rule: types.Rule{
+				Path: ".env.example",
+				Keys: []types.KeyAssertion{{Key: "DATABASE_URL", Matches: "^postgres://"}, {Key: "PORT"}},
+			},
+			expected: true,
+		},
+		{
+			name: "schema",
+			rule: types.Rule{
+				Path:   "config.yaml",
+				Schema: json.RawMessage(`{"type": "object", "required": ["server", "database"]}`),
+			},
+			errContains: "missing required property 'database'",
+		},
+		{
+			name:        "parse error with position",
+			rule:        types.Rule{Path: "broken.yaml"},
+			errContains: "parse error at line 3",
+		},
+		{
+			name:        "missing file",
+			rule:        types.Rule{Path: "missing.toml"},
+			errContains: "does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Type = types.TypeConfigFile
+			tt.rule.Path = filepath.Join(tmpDir, tt.rule.Path)
+
+			result, err := CheckRule(tt.rule)
+			if result != tt.expected {
+				t.Errorf("CheckRule() = %v, expected %v (err: %v)", result, tt.expected, err)
+			}
+			if tt.errContains != "" && (err == nil || !strings.Contains(err.Error(), tt.errContains)) {
+				t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package quest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// EventType names what happened in an Event
+type EventType string
+
+const (
+	EventBegin    EventType = "begin"
+	EventNext     EventType = "next"
+	EventCheck    EventType = "check"
+	EventExplain  EventType = "explain"
+	EventJumpTo   EventType = "jumpTo"
+	EventComplete EventType = "complete"
+	EventReset    EventType = "reset"
+)
+
+var eventTypes = []EventType{EventBegin, EventNext, EventCheck, EventExplain, EventJumpTo, EventComplete, EventReset}
+
+// Sources of check events, the command that ran the check
+const (
+	SourceCheck    = "check"
+	SourceCheckAll = "check --all"
+	SourceWatch    = "watch"
+	SourceLSP      = "lsp"
+)
+
+// Event is one line of .quest/events.jsonl. The log is only ever appended
+// to, so it keeps the history that State forgets.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+
+	// The task the event is about, 1-based, and where jumpTo came from
+	Task   int    `json:"task,omitempty"`
+	TaskID string `json:"taskId,omitempty"`
+	From   int    `json:"from,omitempty"`
+
+	// For begin and complete
+	Journey   string `json:"journey,omitempty"`
+	Completed int    `json:"completed,omitempty"`
+	Total     int    `json:"total,omitempty"`
+
+	// For check
+	Source     string            `json:"source,omitempty"`
+	Status     types.CheckStatus `json:"status,omitempty"`
+	DurationMs int64             `json:"durationMs,omitempty"`
+	Rules      []RuleResult      `json:"rules,omitempty"`
+
+	// Hints given by explain, or annotations added by check --annotate
+	Hints []HintReport `json:"hints,omitempty"`
+}
+
+// logEvent appends event to the activity log. A failure to log never fails
+// the command that did the work.
+func logEvent(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	data, err := json.Marshal(event)
+	if err == nil {
+		err = appendLine(EventsFilePath, data)
+	}
+	if err != nil {
+		format.Warning(fmt.Sprintf("Could not record the %s in the activity log: %v", event.Type, err))
+	}
+}
+
+func appendLine(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// checkEvent describes checking the task at index. Rule details are left
+// out, the reason is enough to tell what went wrong.
+func checkEvent(source string, index int, task types.Task, status types.CheckStatus, duration time.Duration, results []RuleResult) Event {
+	rules := make([]RuleResult, len(results))
+	for i, result := range results {
+		rules[i] = RuleResult{Name: result.Name, Passed: result.Passed, Reason: result.Reason}
+	}
+	return Event{
+		Type:       EventCheck,
+		Task:       index + 1,
+		TaskID:     task.ID,
+		Source:     source,
+		Status:     status,
+		DurationMs: duration.Milliseconds(),
+		Rules:      rules,
+	}
+}
+
+// ReadEvents returns every event in the activity log, oldest first. Lines
+// that can't be decoded, such as one cut short by a crash, are skipped.
+func ReadEvents() ([]Event, error) {
+	f, err := os.Open(EventsFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		if _, statErr := os.Stat(QuestDir); statErr != nil {
+			return nil, statErr
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event Event
+		if json.Unmarshal(scanner.Bytes(), &event) == nil && event.Type != "" {
+			events = append(events, event)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// eventFilter picks the events quest log shows
+type eventFilter struct {
+	Types []EventType
+	Task  string // task number or ID
+	Since time.Time
+}
+
+func (f eventFilter) match(event Event) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			found = found || t == event.Type
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Task != "" && f.Task != strconv.Itoa(event.Task) && f.Task != event.TaskID {
+		return false
+	}
+	return f.Since.IsZero() || !event.Time.Before(f.Since)
+}
+
+// parseEventTypes reads --type values, which may also be comma separated
+func parseEventTypes(values []string) ([]EventType, error) {
+	var selected []EventType
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			known := false
+			for _, t := range eventTypes {
+				if strings.EqualFold(name, string(t)) {
+					selected = append(selected, t)
+					known = true
+				}
+			}
+			if !known {
+				return nil, fmt.Errorf("unknown event type '%s'", name)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// eventTypeList names every event type for help and error messages
+func eventTypeList() string {
+	names := make([]string, len(eventTypes))
+	for i, t := range eventTypes {
+		names[i] = string(t)
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// parseSince reads --since as a duration back from now or as a date
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is neither a duration like 2h nor a date like 2006-01-02", value)
+}
+
+func RunLog(cmd *cobra.Command, args []string) (err error) {
+	report := newReport(cmd, "log")
+	defer func() { err = report.finish(err) }()
+
+	typeFlags, _ := cmd.Flags().GetStringSlice("type")
+	selected, err := parseEventTypes(typeFlags)
+	if err != nil {
+		format.ErrorWithTip("Invalid event type", err, "Use "+eventTypeList())
+		return exitWith(ExitInternal, err)
+	}
+	sinceFlag, _ := cmd.Flags().GetString("since")
+	since, err := parseSince(sinceFlag, time.Now())
+	if err != nil {
+		format.ErrorWithTip("Invalid --since", err, "Use a duration such as 30m or 24h, or a date such as 2006-01-02")
+		return exitWith(ExitInternal, err)
+	}
+	task, _ := cmd.Flags().GetString("task")
+	limit, _ := cmd.Flags().GetInt("limit")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	events, err := ReadEvents()
+	if err != nil {
+		format.ErrorWithTip("Failed to read the activity log", err, "Run 'quest begin' to start a new quest")
+		return commandError(err)
+	}
+
+	filter := eventFilter{Types: selected, Task: task, Since: since}
+	var matched []Event
+	for _, event := range events {
+		if filter.match(event) {
+			matched = append(matched, event)
+		}
+	}
+	total := len(matched)
+	if limit > 0 && total > limit {
+		matched = matched[total-limit:]
+	}
+	report.Events = matched
+
+	format.Header("Quest activity")
+	if len(matched) == 0 {
+		format.Info("No matching events")
+		return nil
+	}
+	for _, event := range matched {
+		printEvent(event, verbose)
+	}
+	format.Newline()
+	if len(matched) < total {
+		format.Dim(fmt.Sprintf("Showing the last %d of %d events, use --limit 0 to see all", len(matched), total))
+	}
+	return nil
+}
+
+// printEvent writes one event as a line, with its rules and hints below
+// when verbose
+func printEvent(event Event, verbose bool) {
+	var parts []string
+	if event.Task > 0 {
+		task := fmt.Sprintf("Task %d", event.Task)
+		if event.TaskID != "" {
+			task += " (" + event.TaskID + ")"
+		}
+		parts = append(parts, task)
+	}
+
+	switch event.Type {
+	case EventBegin:
+		parts = append(parts, event.Journey)
+	case EventJumpTo:
+		if event.From > 0 {
+			parts = append(parts, fmt.Sprintf("from Task %d", event.From))
+		}
+	case EventCheck:
+		passed := 0
+		for _, rule := range event.Rules {
+			if rule.Passed {
+				passed++
+			}
+		}
+		parts = append(parts, fmt.Sprintf("%s %d/%d rules", event.Status, passed, len(event.Rules)))
+		parts = append(parts, (time.Duration(event.DurationMs) * time.Millisecond).String())
+		if event.Source != "" && event.Source != SourceCheck {
+			parts = append(parts, "via "+event.Source)
+		}
+	case EventExplain:
+		parts = append(parts, fmt.Sprintf("%d hint(s)", len(event.Hints)))
+	case EventComplete:
+		parts = append(parts, fmt.Sprintf("%s, %d of %d tasks", event.Journey, event.Completed, event.Total))
+	}
+
+	color := format.ColorPink
+	if event.Type == EventCheck {
+		color = format.ColorGreen
+		if event.Status != types.CheckPass {
+			color = format.ColorRed
+		}
+	}
+	format.Line(fmt.Sprintf("%s%s%s  %s%-8s%s  %s",
+		format.ColorDim, event.Time.Local().Format("2006-01-02 15:04:05"), format.ColorReset,
+		color, event.Type, format.ColorReset, strings.Join(parts, " · ")))
+
+	if !verbose {
+		return
+	}
+	for _, rule := range event.Rules {
+		mark := "✓"
+		if !rule.Passed {
+			mark = "✗"
+		}
+		line := fmt.Sprintf("    %s %s", mark, rule.Name)
+		if rule.Reason != "" {
+			line += " - " + rule.Reason
+		}
+		format.Dim(line)
+	}
+	for _, hint := range event.Hints {
+		format.Dim(fmt.Sprintf("    %s:%d - %s", hint.File, hint.Line, hint.Comment))
+	}
+}
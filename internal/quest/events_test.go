@@ -0,0 +1,120 @@
+package quest
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestReadEventsSkipsBrokenLines(t *testing.T) {
+	chdirTemp(t)
+	logEvent(Event{Type: EventNext, Task: 1, TaskID: "setup"})
+	appendLine(EventsFilePath, []byte(`{"type": "check", "task": 1, "rul`))
+	logEvent(Event{Type: EventJumpTo, Task: 3, From: 1})
+
+	events, err := ReadEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Type != EventNext || events[1].Type != EventJumpTo {
+		t.Fatalf("Expected the two complete events, got %+v", events)
+	}
+	if events[0].Time.IsZero() {
+		t.Error("Expected events to be timestamped")
+	}
+}
+
+func TestRunCheckLogsEvent(t *testing.T) {
+	writeRegressionQuest(t)
+	RunCheck(testCommand(t, "check"), nil)
+
+	events, err := ReadEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected one check event, got %+v", events)
+	}
+	e := events[0]
+	if e.Type != EventCheck || e.TaskID != "handlers" || e.Source != SourceCheck || e.Status != types.CheckFail || len(e.Rules) == 0 {
+		t.Errorf("Unexpected check event: %+v", e)
+	}
+}
+
+func TestRunLogFilters(t *testing.T) {
+	chdirTemp(t)
+	old := time.Now().Add(-48 * time.Hour)
+	logEvent(Event{Time: old, Type: EventBegin, Journey: "Web API"})
+	logEvent(Event{Type: EventNext, Task: 1, TaskID: "setup"})
+	logEvent(Event{Type: EventCheck, Task: 1, TaskID: "setup", Status: types.CheckFail})
+	logEvent(Event{Type: EventCheck, Task: 2, TaskID: "handlers", Status: types.CheckPass})
+
+	tests := []struct {
+		name  string
+		flags []string
+		want  int
+	}{
+		{"everything", nil, 4},
+		{"by type", []string{"--type", "check"}, 2},
+		{"by several types", []string{"--type", "begin,next"}, 2},
+		{"by task number", []string{"--task", "1"}, 2},
+		{"by task ID", []string{"--task", "handlers"}, 1},
+		{"since a duration", []string{"--since", "24h"}, 3},
+		{"limited", []string{"--limit", "1"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := captureReport(t, testCommand(t, "log", tt.flags...), RunLog)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Events) != tt.want {
+				t.Errorf("Expected %d events, got %+v", tt.want, report.Events)
+			}
+		})
+	}
+
+	report, _ := captureReport(t, testCommand(t, "log", "--limit", "1"), RunLog)
+	if len(report.Events) == 1 && report.Events[0].TaskID != "handlers" {
+		t.Errorf("Expected the limit to keep the latest event, got %+v", report.Events[0])
+	}
+	if _, err := captureReport(t, testCommand(t, "log", "--type", "push"), RunLog); ExitCode(err) != ExitInternal {
+		t.Errorf("Expected an unknown type to fail, got %v", err)
+	}
+}
+
+func TestEventTypeList(t *testing.T) {
+	list := eventTypeList()
+	for _, eventType := range eventTypes {
+		if !strings.Contains(list, string(eventType)) {
+			t.Errorf("Expected %q to name %s", list, eventType)
+		}
+	}
+}
+
+func TestRunLogWithoutQuest(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if _, err := captureReport(t, testCommand(t, "log"), RunLog); ExitCode(err) != ExitNotInitialized {
+		t.Errorf("Expected exit code %d without a quest, got %v", ExitNotInitialized, err)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 2, 12, 0, 0, 0, time.Local)
+	if got, _ := parseSince("2h", now); !got.Equal(now.Add(-2 * time.Hour)) {
+		t.Errorf("Expected two hours ago, got %v", got)
+	}
+	if got, _ := parseSince("2024-05-01", now); !got.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Expected the start of the day, got %v", got)
+	}
+	if _, err := parseSince("yesterday", now); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
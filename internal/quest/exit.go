@@ -0,0 +1,65 @@
+package quest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Exit codes quest finishes with, so scripts and CI can tell outcomes apart
+const (
+	ExitPass           = 0 // the command succeeded and every check passed
+	ExitFail           = 1 // a check failed or the command was refused
+	ExitWarn           = 2 // checks passed but left warnings
+	ExitNotInitialized = 3 // there is no quest in this directory
+	ExitInternal       = 4 // quest couldn't run: bad arguments, unreadable data, failed writes
+)
+
+// ExitError makes a command finish with Code. The problem has already been
+// printed, so it only needs to reach Execute.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	errChecksFailed = errors.New("checks failed")
+	errChecksWarned = errors.New("checks passed with warnings")
+	errCancelled    = errors.New("cancelled")
+)
+
+func exitWith(code int, err error) error {
+	return &ExitError{Code: code, Err: err}
+}
+
+// commandError wraps an error a command already printed. Missing quest files
+// mean the quest was never started, anything else is an internal error.
+func commandError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return exitWith(ExitNotInitialized, err)
+	}
+	return exitWith(ExitInternal, err)
+}
+
+// ExitCode returns the exit code for an error returned by a command
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitPass
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitInternal
+}
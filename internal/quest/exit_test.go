@@ -0,0 +1,121 @@
+package quest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", err: nil, want: ExitPass},
+		{name: "failed checks", err: exitWith(ExitFail, errChecksFailed), want: ExitFail},
+		{name: "warnings", err: exitWith(ExitWarn, errChecksWarned), want: ExitWarn},
+		{name: "missing quest", err: commandError(os.ErrNotExist), want: ExitNotInitialized},
+		{name: "other failure", err: commandError(errors.New("disk full")), want: ExitInternal},
+		{name: "unreported error", err: errors.New("unknown flag"), want: ExitInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := ExitCode(RunCheck(testCommand(t, "check"), nil)); got != ExitNotInitialized {
+		t.Errorf("Expected exit code %d without a quest, got %d", ExitNotInitialized, got)
+	}
+	os.Chdir(wd)
+
+	writeRegressionQuest(t)
+	if got := ExitCode(RunCheck(testCommand(t, "check"), nil)); got != ExitFail {
+		t.Errorf("Expected exit code %d for a failing task, got %d", ExitFail, got)
+	}
+	os.WriteFile("handlers.go", []byte("package main\n"), 0644)
+	if got := ExitCode(RunCheck(testCommand(t, "check"), nil)); got != ExitPass {
+		t.Errorf("Expected exit code %d for a passing task, got %d", ExitPass, got)
+	}
+}
+
+func TestCI(t *testing.T) {
+	writeRegressionQuest(t)
+
+	// Only the current task fails, it isn't completed yet
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+	if got := ExitCode(RunCI(testCommand(t, "ci"), nil)); got != ExitPass {
+		t.Errorf("Expected incomplete tasks not to fail CI, got %d", got)
+	}
+	if got := ExitCode(RunCI(testCommand(t, "ci", "--require-all"), nil)); got != ExitFail {
+		t.Errorf("Expected --require-all to fail on the current task, got %d", got)
+	}
+
+	// A completed task that regressed always fails
+	os.Remove("main.go")
+	if got := ExitCode(RunCI(testCommand(t, "ci"), nil)); got != ExitFail {
+		t.Errorf("Expected a regressed task to fail CI, got %d", got)
+	}
+
+	// A committed cache claiming the task passes must not be believed
+	cache := LoadRuleCache()
+	rule := types.Rule{Type: types.TypeExists, Name: "main.go", Path: "main.go"}
+	inputs, _ := cache.ruleInputs(rule)
+	cache.store("main.go", rule, inputs, []RuleResult{{Name: "main.go", Passed: true}})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ExitCode(RunCI(testCommand(t, "ci"), nil)); got != ExitFail {
+		t.Errorf("Expected CI to ignore the cache, got %d", got)
+	}
+	if data, err := os.ReadFile(filepath.Join(QuestDir, ".gitignore")); err != nil || !strings.Contains(string(data), "cache.json") {
+		t.Errorf("Expected the cache to be kept out of git, got %q (%v)", data, err)
+	}
+
+	state, _ := LoadState()
+	if len(state.RegressedTaskIDs) != 0 {
+		t.Error("Expected CI not to save anything")
+	}
+}
+
+func TestNextDoesNotPromptInCI(t *testing.T) {
+	writeRegressionQuest(t)
+	t.Setenv("CI", "true")
+
+	state, _ := LoadState()
+	state.CurrentTaskIndex = 1
+	state.LastCheck = &types.CheckResult{Status: types.CheckFail}
+	plan, _ := LoadPlan()
+	UploadStateAndPlan(state, plan)
+
+	cmd := testCommand(t, "next")
+	if got := ExitCode(RunNext(cmd, nil)); got != ExitFail {
+		t.Errorf("Expected next to refuse without a passing check, got %d", got)
+	}
+	if state, _ := LoadState(); state.CurrentTaskIndex != 1 {
+		t.Errorf("Expected the cursor to stay on task 2, got %d", state.CurrentTaskIndex+1)
+	}
+
+	cmd.Flags().Set("force", "true")
+	if got := ExitCode(RunNext(cmd, nil)); got != ExitPass {
+		t.Errorf("Expected next --force to move on, got %d", got)
+	}
+	if state, _ := LoadState(); state.CurrentTaskIndex != 2 {
+		t.Errorf("Expected the cursor on task 3, got %d", state.CurrentTaskIndex+1)
+	}
+}
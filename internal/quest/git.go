@@ -0,0 +1,184 @@
+package quest
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+// runGit runs git in the current directory and returns trimmed stdout
+func runGit(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], firstLines(msg, 1))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func ensureGitRepository() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git is not installed")
+	}
+	if out, err := runGit("rev-parse", "--is-inside-work-tree"); err != nil || out != "true" {
+		return fmt.Errorf("not a git repository, run 'git init' first")
+	}
+	return nil
+}
+
+// headCommit returns the current HEAD hash, or "" when there is no repository
+// or no commit yet
+func headCommit() string {
+	hash, err := runGit("rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return hash
+}
+
+// markTaskStarted records when the current task started so git rules can
+// look only at the work done for it
+func markTaskStarted(state *types.State) {
+	state.TaskStartedAt = time.Now()
+	state.TaskStartCommit = headCommit()
+}
+
+// commitsSinceTaskStart lists "hash subject" lines for commits made since the
+// task started, newest first
+func commitsSinceTaskStart(opts CheckOptions) ([]string, error) {
+	if headCommit() == "" {
+		return nil, nil
+	}
+
+	args := []string{"log", "--format=%H %s"}
+	switch {
+	case opts.TaskStartCommit != "":
+		args = append(args, opts.TaskStartCommit+"..HEAD")
+	case !opts.TaskStartedAt.IsZero():
+		args = append(args, "--since="+opts.TaskStartedAt.Format(time.RFC3339), "HEAD")
+	default:
+		args = append(args, "HEAD")
+	}
+
+	out, err := runGit(args...)
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+func checkGitRule(rule types.Rule, opts CheckOptions) (bool, error) {
+	if err := ensureGitRepository(); err != nil {
+		return false, err
+	}
+
+	switch rule.Type {
+	case types.TypeGitCommits:
+		commits, err := commitsSinceTaskStart(opts)
+		if err != nil {
+			return false, err
+		}
+		expected := rule.Min
+		if expected == 0 {
+			expected = 1
+		}
+		if len(commits) < expected {
+			return false, fmt.Errorf("found %d commit(s) since the task started, expected at least %d", len(commits), expected)
+		}
+		return true, nil
+
+	case types.TypeGitClean:
+		// .quest changes on every run, so it never counts as dirty
+		out, err := runGit("status", "--porcelain", "--", ".", ":(exclude)"+QuestFolderName)
+		if err != nil {
+			return false, err
+		}
+		if out == "" {
+			return true, nil
+		}
+		var files []string
+		for _, line := range strings.Split(out, "\n") {
+			files = append(files, strings.TrimSpace(line[2:]))
+		}
+		if len(files) > maxReportedProblems {
+			files = append(files[:maxReportedProblems], fmt.Sprintf("and %d more", len(files)-maxReportedProblems))
+		}
+		return false, fmt.Errorf("working tree has uncommitted changes: %s", strings.Join(files, ", "))
+
+	case types.TypeGitBranch:
+		regex, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return false, err
+		}
+		branch, err := runGit("symbolic-ref", "--short", "-q", "HEAD")
+		if err != nil || branch == "" {
+			return false, fmt.Errorf("HEAD is detached, check out a branch matching '%s'", rule.Pattern)
+		}
+		if !regex.MatchString(branch) {
+			return false, fmt.Errorf("on branch '%s', expected one matching '%s'", branch, rule.Pattern)
+		}
+		return true, nil
+
+	case types.TypeGitMessage:
+		regex, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return false, err
+		}
+		commits, err := commitsSinceTaskStart(opts)
+		if err != nil {
+			return false, err
+		}
+		if len(commits) == 0 {
+			return false, fmt.Errorf("no commits since the task started")
+		}
+		var offending []string
+		for _, commit := range commits {
+			parts := strings.SplitN(commit, " ", 2)
+			subject := ""
+			if len(parts) == 2 {
+				subject = parts[1]
+			}
+			if !regex.MatchString(subject) {
+				offending = append(offending, fmt.Sprintf("%s '%s'", parts[0][:7], subject))
+			}
+		}
+		if len(offending) > 0 {
+			if len(offending) > maxReportedProblems {
+				offending = append(offending[:maxReportedProblems], fmt.Sprintf("and %d more", len(offending)-maxReportedProblems))
+			}
+			return false, fmt.Errorf("commit message(s) don't match '%s': %s", rule.Pattern, strings.Join(offending, ", "))
+		}
+		return true, nil
+	}
+	return false, fmt.Errorf("rule type '%s' is not a git rule", rule.Type)
+}
+
+func gitSuccessReason(rule types.Rule, opts CheckOptions) string {
+	switch rule.Type {
+	case types.TypeGitCommits, types.TypeGitMessage:
+		commits, _ := commitsSinceTaskStart(opts)
+		if rule.Type == types.TypeGitMessage {
+			return fmt.Sprintf("- %d commit message(s) match '%s'", len(commits), rule.Pattern)
+		}
+		return fmt.Sprintf("- %d commit(s) since the task started", len(commits))
+	case types.TypeGitClean:
+		return "- working tree is clean"
+	case types.TypeGitBranch:
+		branch, _ := runGit("symbolic-ref", "--short", "-q", "HEAD")
+		return fmt.Sprintf("- on branch '%s'", branch)
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package quest
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func gitInTest(t *testing.T, args ...string) {
+	t.Helper()
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+func TestCheckGitRules(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	t.Setenv("GIT_AUTHOR_NAME", "Learner")
+	t.Setenv("GIT_AUTHOR_EMAIL", "learner@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Learner")
+	t.Setenv("GIT_COMMITTER_EMAIL", "learner@example.com")
+
+	if ok, err := checkGitRule(types.Rule{Type: types.TypeGitClean}, CheckOptions{}); ok || err == nil {
+		t.Error("Expected git rules to fail outside a repository")
+	}
+
+	gitInTest(t, "init", "-q", "-b", "main")
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+	gitInTest(t, "add", ".")
+	gitInTest(t, "commit", "-q", "-m", "chore: initial")
+
+	state := &types.State{}
+	markTaskStarted(state)
+	opts := CheckOptions{TaskStartedAt: state.TaskStartedAt, TaskStartCommit: state.TaskStartCommit}
+	if state.TaskStartCommit == "" {
+		t.Fatal("Expected the task start commit to be recorded")
+	}
+
+	commitsRule := types.Rule{Type: types.TypeGitCommits, Min: 1}
+	if ok, _ := checkGitRule(commitsRule, opts); ok {
+		t.Error("Expected no commits since the task started")
+	}
+
+	// Dirty tree, but .quest never counts
+	os.MkdirAll(QuestDir, 0755)
+	os.WriteFile(StateFilePath, []byte("{}"), 0644)
+	os.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0644)
+	if ok, err := checkGitRule(types.Rule{Type: types.TypeGitClean}, opts); ok || err == nil {
+		t.Error("Expected a modified file to make the tree dirty")
+	}
+
+	gitInTest(t, "commit", "-q", "-am", "add main")
+
+	tests := []struct {
+		name     string
+		rule     types.Rule
+		expected bool
+	}{
+		{"one commit since start", commitsRule, true},
+		{"two commits since start", types.Rule{Type: types.TypeGitCommits, Min: 2}, false},
+		{"clean tree ignores .quest", types.Rule{Type: types.TypeGitClean}, true},
+		{"branch matches", types.Rule{Type: types.TypeGitBranch, Pattern: "^main$"}, true},
+		{"branch does not match", types.Rule{Type: types.TypeGitBranch, Pattern: "^feature/"}, false},
+		{"message convention broken", types.Rule{Type: types.TypeGitMessage, Pattern: `^(feat|fix|chore): `}, false},
+		{"message convention loose", types.Rule{Type: types.TypeGitMessage, Pattern: `main`}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := checkGitRule(tt.rule, opts)
+			if ok != tt.expected {
+				t.Errorf("checkGitRule() = %v, want %v (err: %v)", ok, tt.expected, err)
+			}
+		})
+	}
+}
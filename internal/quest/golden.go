@@ -0,0 +1,365 @@
+package quest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// normalizers are the built-in replacements a rule can opt into so goldens
+// don't churn on values that change between runs
+var normalizers = map[string]types.Replacement{
+	"timestamps": {Pattern: `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`, With: "<TIMESTAMP>"},
+	"uuids":      {Pattern: `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, With: "<UUID>"},
+	"ports":      {Pattern: `(localhost|127\.0\.0\.1|0\.0\.0\.0|\[::1?\]):\d+`, With: "$1:<PORT>"},
+	"durations":  {Pattern: `\b\d+(\.\d+)?(ns|µs|us|ms|s|m|h)\b`, With: "<DURATION>"},
+}
+
+// evaluateOutputRule runs a "command" or "http" rule and, when the rule has a
+// golden, compares the normalized output with it (or rewrites it when
+// opts.UpdateGoldens is set). detail holds a unified diff on mismatch.
+func evaluateOutputRule(ctx context.Context, rule types.Rule, opts CheckOptions) (passed bool, reason string, detail string) {
+	var output string
+	var err error
+	switch rule.Type {
+	case types.TypeCommand:
+		output, err = runCommandRule(ctx, rule, opts.Workspace)
+	case types.TypeHTTP:
+		output, err = runHTTPRule(ctx, rule, opts.Workspace)
+	default:
+		return false, fmt.Sprintf("rule type '%s' has no output", rule.Type), ""
+	}
+	if err != nil {
+		return false, err.Error(), ""
+	}
+
+	if rule.Golden == "" && rule.GoldenText == "" {
+		return true, outputSuccessReason(rule), ""
+	}
+
+	actual, err := normalizeOutput(rule, output)
+	if err != nil {
+		return false, err.Error(), ""
+	}
+
+	if opts.UpdateGoldens {
+		path, err := writeGolden(rule.Golden, actual)
+		if err != nil {
+			return false, err.Error(), ""
+		}
+		return true, fmt.Sprintf("- golden updated (%s)", path), ""
+	}
+
+	expected, source, err := readGolden(rule)
+	if err != nil {
+		return false, err.Error(), ""
+	}
+	if expected == actual {
+		return true, fmt.Sprintf("- output matches %s", source), ""
+	}
+
+	return false, fmt.Sprintf("output differs from %s", source), UnifiedDiff(expected, actual, source, "actual")
+}
+
+// runGoldenUpdate rewrites the goldens of the current task, or of the tasks
+// picked with --task, --chapter or --all, from the output of the learner's
+// program. Only rules with a golden run. Nothing is recorded: no task is
+// completed and the last check, snapshots, stats and activity log stay as
+// they were, so updating can't stand in for passing.
+func runGoldenUpdate(cmd *cobra.Command, report *Report, state *types.State, plan *types.Plan) error {
+	tasks := FlattenTasks(plan)
+	all, _ := cmd.Flags().GetBool("all")
+	taskFlag, _ := cmd.Flags().GetString("task")
+	chapterFlag, _ := cmd.Flags().GetString("chapter")
+
+	var indexes []int
+	switch {
+	case all:
+		for i := range tasks {
+			indexes = append(indexes, i)
+		}
+	case taskFlag != "" || chapterFlag != "":
+		selected, err := selectTasks(plan, taskFlag, chapterFlag)
+		if err != nil {
+			format.ErrorWithTip("Invalid task selection", err, "Use a task number or ID with --task, or a chapter (2) or quest (2.1) with --chapter")
+			return exitWith(ExitInternal, err)
+		}
+		indexes = selected
+	case state.CurrentTaskIndex < len(tasks):
+		indexes = []int{state.CurrentTaskIndex}
+	default:
+		format.ErrorWithTip("The quest is finished", nil, "Pick the goldens to update with --task, --chapter or --all")
+		return exitWith(ExitInternal, errors.New("no current task"))
+	}
+
+	// Each task keeps only its rules with a golden
+	var selected []types.Task
+	var selectedIndexes []int
+	for _, i := range indexes {
+		task := tasks[i]
+		task.Validation.Rules = nil
+		for _, rule := range tasks[i].Validation.Rules {
+			if rule.Golden != "" {
+				task.Validation.Rules = append(task.Validation.Rules, rule)
+			}
+		}
+		if len(task.Validation.Rules) > 0 {
+			selected = append(selected, task)
+			selectedIndexes = append(selectedIndexes, i)
+		}
+	}
+	if len(selected) == 0 {
+		format.Info("No rules with a golden to update")
+		return nil
+	}
+
+	opts := checkOptions(cmd, state)
+	defer opts.Workspace.Close()
+	holdLockForCheck(cmd, selected, opts)
+	ctx, cancel := checkContext(cmd)
+	defer cancel()
+
+	updated, failed := 0, 0
+	for n, task := range selected {
+		format.CheckHeader(selectedIndexes[n]+1, task.Title)
+		var results []RuleResult
+		taskFailed := 0
+		EvaluateRules(ctx, task.Validation.Rules, opts, func(_ int, ruleResults []RuleResult) {
+			for _, result := range ruleResults {
+				results = append(results, result)
+				if result.Updated {
+					updated++
+					format.CheckUpdated(result.Name, result.Reason)
+				} else {
+					taskFailed++
+					format.CheckFail(result.Name, result.Reason)
+					if result.Detail != "" {
+						format.CheckDetail(result.Detail)
+					}
+				}
+			}
+		})
+		if errors.Is(ctx.Err(), context.Canceled) {
+			format.Newline()
+			format.Warning("Update cancelled")
+			return exitWith(ExitFail, errCancelled)
+		}
+		failed += taskFailed
+
+		taskInfo := taskReport(selectedIndexes[n], task, state)
+		taskInfo.Check = taskCheck(0, taskFailed, results)
+		taskInfo.Check.Updated = len(results) - taskFailed
+		if taskFailed == 0 {
+			taskInfo.Check.Status = types.CheckUpdated
+		}
+		report.Tasks = append(report.Tasks, taskInfo)
+	}
+
+	format.Newline()
+	format.Info(fmt.Sprintf("Updated %d golden(s), nothing was recorded as checked", updated))
+	if failed > 0 {
+		format.Warning(fmt.Sprintf("%d golden(s) could not be updated", failed))
+		return exitWith(ExitFail, errChecksFailed)
+	}
+	report.Status = string(types.CheckUpdated)
+	format.CommandHint("Review the goldens, then check your work with", "quest check")
+	return nil
+}
+
+func outputSuccessReason(rule types.Rule) string {
+	if rule.Type == types.TypeHTTP {
+		return fmt.Sprintf("- %s %s returned %d", httpMethod(rule), rule.URL, expectedStatus(rule))
+	}
+	return fmt.Sprintf("- '%s' exited with %d", rule.Command, rule.ExitCode)
+}
+
+func runCommandRule(ctx context.Context, rule types.Rule, workspace *workspaceCopy) (string, error) {
+	fields := append(strings.Fields(rule.Command), rule.Args...)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("command rule is missing 'command'")
+	}
+
+	sb, err := newSandbox(workspace)
+	if err != nil {
+		return "", err
+	}
+	defer sb.Close()
+
+	cmd, err := sb.Command(fields[0], fields[1:]...)
+	if err != nil {
+		return "", fmt.Errorf("failed to run '%s': %w", rule.Command, err)
+	}
+	cmd.Stdin = strings.NewReader(rule.Stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = sb.Run(ctx, cmd)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+
+	exitCode := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		return "", fmt.Errorf("failed to run '%s': %w", rule.Command, err)
+	}
+
+	if exitCode != rule.ExitCode {
+		msg := fmt.Sprintf("'%s' exited with %d, expected %d", rule.Command, exitCode, rule.ExitCode)
+		if stderr.Len() > 0 {
+			msg += ": " + firstLines(stderr.String(), 3)
+		}
+		return "", errors.New(msg)
+	}
+
+	return stdout.String(), nil
+}
+
+func runHTTPRule(ctx context.Context, rule types.Rule, workspace *workspaceCopy) (string, error) {
+	baseURL := rule.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	stop, err := startServer(ctx, rule.Command, baseURL, workspace)
+	if err != nil {
+		return "", err
+	}
+	defer stop()
+
+	var body io.Reader
+	if rule.Body != "" {
+		body = strings.NewReader(rule.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, httpMethod(rule), strings.TrimRight(baseURL, "/")+rule.URL, body)
+	if err != nil {
+		return "", err
+	}
+	if rule.Body != "" && json.Valid([]byte(rule.Body)) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != expectedStatus(rule) {
+		return "", fmt.Errorf("%s %s returned %d, expected %d", httpMethod(rule), rule.URL, resp.StatusCode, expectedStatus(rule))
+	}
+
+	// Pretty-print JSON so golden diffs are line based
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, respBody, "", "  ") == nil {
+		respBody = pretty.Bytes()
+	}
+	return fmt.Sprintf("status: %d\n\n%s\n", resp.StatusCode, strings.TrimRight(string(respBody), "\n")), nil
+}
+
+func httpMethod(rule types.Rule) string {
+	if rule.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(rule.Method)
+}
+
+func expectedStatus(rule types.Rule) int {
+	if rule.Status == 0 {
+		return http.StatusOK
+	}
+	return rule.Status
+}
+
+// normalizeOutput applies line ending cleanup, the named normalizers and the
+// rule's own replacements, in that order
+func normalizeOutput(rule types.Rule, output string) (string, error) {
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+
+	replacements := make([]types.Replacement, 0, len(rule.Normalize)+len(rule.Replace))
+	for _, name := range rule.Normalize {
+		r, ok := normalizers[name]
+		if !ok {
+			return "", fmt.Errorf("unknown normalizer '%s'", name)
+		}
+		replacements = append(replacements, r)
+	}
+	replacements = append(replacements, rule.Replace...)
+
+	for _, r := range replacements {
+		regex, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid replace pattern '%s': %w", r.Pattern, err)
+		}
+		output = regex.ReplaceAllString(output, r.With)
+	}
+	return output, nil
+}
+
+// goldenPath maps a golden name into .quest/goldens, refusing names that
+// would escape it
+func goldenPath(name string) (string, error) {
+	clean := filepath.Clean(name)
+	if name == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid golden name '%s'", name)
+	}
+	return filepath.Join(GoldensFolderPath, clean), nil
+}
+
+// readGolden prefers the workspace golden and falls back to the text shipped
+// with the template
+func readGolden(rule types.Rule) (string, string, error) {
+	if rule.Golden != "" {
+		path, err := goldenPath(rule.Golden)
+		if err != nil {
+			return "", "", err
+		}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return string(data), path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", "", err
+		}
+	}
+	if rule.GoldenText != "" {
+		return rule.GoldenText, "template golden", nil
+	}
+	return "", "", fmt.Errorf("golden '%s' not found, run 'quest check --update-goldens' to create it", rule.Golden)
+}
+
+func writeGolden(name, content string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("rule has no 'golden' name to update")
+	}
+	path, err := goldenPath(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
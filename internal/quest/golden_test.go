@@ -0,0 +1,191 @@
+package quest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestNormalizeOutput(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      types.Rule
+		input     string
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "timestamps and uuids",
+			rule:     types.Rule{Normalize: []string{"timestamps", "uuids"}},
+			input:    "created 2024-05-01T10:20:30.123Z id=0b7f2c1e-1a2b-4c3d-8e9f-0123456789ab\r\n",
+			expected: "created <TIMESTAMP> id=<UUID>\n",
+		},
+		{
+			name:     "ports and durations",
+			rule:     types.Rule{Normalize: []string{"ports", "durations"}},
+			input:    "listening on localhost:54321 after 12.5ms",
+			expected: "listening on localhost:<PORT> after <DURATION>",
+		},
+		{
+			name:     "custom replacement",
+			rule:     types.Rule{Replace: []types.Replacement{{Pattern: `pid \d+`, With: "pid N"}}},
+			input:    "started pid 4242",
+			expected: "started pid N",
+		},
+		{
+			name:      "unknown normalizer",
+			rule:      types.Rule{Normalize: []string{"colors"}},
+			input:     "x",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeOutput(tt.rule, tt.input)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeOutput() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("normalizeOutput() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGoldenPath(t *testing.T) {
+	if _, err := goldenPath("../state.json"); err == nil {
+		t.Error("Expected names escaping the goldens folder to be rejected")
+	}
+	path, err := goldenPath("cli/help.txt")
+	if err != nil {
+		t.Fatalf("goldenPath() error = %v", err)
+	}
+	if path != filepath.Join(GoldensFolderPath, "cli", "help.txt") {
+		t.Errorf("Unexpected golden path %s", path)
+	}
+}
+
+func TestCommandGolden(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	rule := types.Rule{
+		Type:      types.TypeCommand,
+		Command:   "echo",
+		Args:      []string{"hello at 2024-05-01 10:20:30"},
+		Golden:    "echo.txt",
+		Normalize: []string{"timestamps"},
+	}
+
+	// Missing golden fails with a hint
+	if passed, reason, _ := evaluateOutputRule(context.Background(), rule, CheckOptions{}); passed || !strings.Contains(reason, "--update-goldens") {
+		t.Errorf("Expected a missing golden to fail with a hint, got passed=%v reason=%q", passed, reason)
+	}
+
+	// Update writes the normalized output
+	if passed, _, _ := evaluateOutputRule(context.Background(), rule, CheckOptions{UpdateGoldens: true}); !passed {
+		t.Fatal("Expected update mode to pass")
+	}
+	data, err := os.ReadFile(filepath.Join(GoldensFolderPath, "echo.txt"))
+	if err != nil {
+		t.Fatalf("Golden was not written: %v", err)
+	}
+	if string(data) != "hello at <TIMESTAMP>\n" {
+		t.Errorf("Unexpected golden content %q", data)
+	}
+
+	// A different timestamp still matches
+	rule.Args = []string{"hello at 2031-12-31 23:59:59"}
+	if passed, reason, _ := evaluateOutputRule(context.Background(), rule, CheckOptions{}); !passed {
+		t.Errorf("Expected output to match the golden: %s", reason)
+	}
+
+	// A real change fails with a diff
+	rule.Args = []string{"goodbye"}
+	passed, _, detail := evaluateOutputRule(context.Background(), rule, CheckOptions{})
+	if passed {
+		t.Fatal("Expected changed output to fail")
+	}
+	if !strings.Contains(detail, "-hello at <TIMESTAMP>") || !strings.Contains(detail, "+goodbye") {
+		t.Errorf("Expected a diff in the detail, got:\n%s", detail)
+	}
+}
+
+func TestCommandExitCode(t *testing.T) {
+	rule := types.Rule{Type: types.TypeCommand, Command: "sh -c", Args: []string{"echo oops >&2; exit 3"}}
+	passed, reason, _ := evaluateOutputRule(context.Background(), rule, CheckOptions{})
+	if passed || !strings.Contains(reason, "exited with 3, expected 0") || !strings.Contains(reason, "oops") {
+		t.Errorf("Unexpected result passed=%v reason=%q", passed, reason)
+	}
+
+	rule.ExitCode = 3
+	if passed, reason, _ := evaluateOutputRule(context.Background(), rule, CheckOptions{}); !passed {
+		t.Errorf("Expected the declared exit code to pass: %s", reason)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "one\ntwo\nthree\n"
+	b := "one\n2\nthree\nfour\n"
+
+	expected := "--- a\n+++ b\n@@ -1,3 +1,4 @@\n one\n-two\n+2\n three\n+four\n"
+	if got := UnifiedDiff(a, b, "a", "b"); got != expected {
+		t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, expected)
+	}
+	if got := UnifiedDiff(a, a, "a", "b"); got != "" {
+		t.Errorf("Expected no diff for equal input, got %q", got)
+	}
+}
+
+func TestCheckUpdateGoldensRecordsNothing(t *testing.T) {
+	chdirTemp(t)
+	task := types.Task{ID: "cli", Title: "Print a greeting", Validation: types.Validation{Rules: []types.Rule{
+		{Type: types.TypeCommand, Name: "greeting", Command: "echo", Args: []string{"hello"}, Golden: "greeting.txt"},
+	}}}
+	plan := &types.Plan{Version: Version, NumberOfTasks: 1,
+		Chapters: []types.Chapter{{Quests: []types.Quest{{Tasks: []types.Task{task}}}}}}
+	state := &types.State{Version: Version, QuestStarted: true}
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := captureReport(t, testCommand(t, "check", "--update-goldens"), RunCheck)
+	if err != nil {
+		t.Fatalf("Expected the update to succeed, got %v", err)
+	}
+	if report.Status != "updated" || len(report.Tasks) != 1 || report.Tasks[0].Check.Status != types.CheckUpdated {
+		t.Errorf("Expected an updated status rather than a pass, got %+v", report)
+	}
+	if data, _ := os.ReadFile(filepath.Join(GoldensFolderPath, "greeting.txt")); string(data) != "hello\n" {
+		t.Errorf("Expected the golden to be written, got %q", data)
+	}
+
+	state, err = LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.CompletedTaskIDs) != 0 || state.LastCheck != nil || state.TaskStats != nil {
+		t.Errorf("Expected the update to record nothing, got %+v", state)
+	}
+	if _, err := snapshotFor("cli", SnapshotPass); err == nil {
+		t.Error("Expected no pass snapshot")
+	}
+	if events, _ := ReadEvents(); len(events) != 0 {
+		t.Errorf("Expected no events, got %+v", events)
+	}
+}
@@ -1,11 +1,15 @@
 package quest
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	copilot_helper "github.com/jovanpet/quest/internal/copilot"
@@ -26,24 +30,36 @@ func FlattenTasks(p *types.Plan) []types.Task {
 	return tasks
 }
 
-func RunBegin(cmd *cobra.Command, args []string) {
-	if _, err := os.Stat(QuestFolderName); err == nil {
-		format.Warning("Quest started and .quest directory already exists.")
-		return
+func RunBegin(cmd *cobra.Command, args []string) error {
+	if name, _ := cmd.Flags().GetString("name"); name != "" {
+		if err := ValidateQuestName(name); err != nil {
+			format.Error("Invalid quest name", err)
+			return exitWith(ExitInternal, err)
+		}
+		UseQuest(name)
+	}
+	if _, err := os.Stat(QuestDir); err == nil {
+		format.Warning(fmt.Sprintf("A quest named '%s' already exists in %s.", QuestName, QuestDir))
+		format.CommandHint("Start another one next to it with", "quest begin --name <name>")
+		return exitWith(ExitFail, errors.New("quest already started"))
 	}
 
-	err := runBegin()
+	snapshotModule, _ := cmd.Flags().GetBool("snapshot-module")
+	err := runBegin(snapshotModule, workspaceOf(invocationDir))
 	if err != nil {
-		format.Warning("Trying to remove .quest folder due to error")
-		err = os.RemoveAll(QuestFolderName)
-		if err != nil {
-			format.ErrorWithTip("Failed to remove .quest folder", err, "Try manually deleting the .quest folder")
+		format.Warning(fmt.Sprintf("Trying to remove %s due to error", QuestDir))
+		if removeErr := os.RemoveAll(QuestDir); removeErr != nil {
+			format.ErrorWithTip(fmt.Sprintf("Failed to remove %s", QuestDir), removeErr, fmt.Sprintf("Try manually deleting %s", QuestDir))
 		}
-		return
+		return exitWith(ExitInternal, err)
 	}
+	if err := SetCurrentQuest(QuestName); err != nil {
+		format.Warning(fmt.Sprintf("Could not make '%s' the current quest: %v", QuestName, err))
+	}
+	return nil
 }
 
-func runBegin() error {
+func runBegin(snapshotModule bool, workspace string) error {
 
 	format.Header("Starting a new quest")
 
@@ -52,11 +68,15 @@ func runBegin() error {
 	fmt.Printf("  %sLearn by building with guided tasks, AI hints, and instant validation.%s\n", format.ColorDim, format.ColorReset)
 	fmt.Println()
 
-	err := os.MkdirAll(QuestFolderName, 0755)
+	err := os.MkdirAll(QuestDir, 0755)
 	if err != nil {
 		format.ErrorWithTip("Error creating directory for the quest", err, "Check folder permissions")
 		return err
 	}
+	if err := writeQuestGitignore(); err != nil {
+		format.ErrorWithTip("Error creating the quest's .gitignore", err, "Check folder permissions")
+		return err
+	}
 
 	// Step 1: Select wizard mode
 	mode, cancelled, err := prompt.SelectWizardMode()
@@ -157,22 +177,26 @@ func runBegin() error {
 	}
 
 	plan.NumberOfTasks = countTasks(*plan)
+	plan.Version = Version
 
 	firstState := &types.State{
-		Version:          1,
+		Version:          Version,
 		CurrentTaskIndex: 0,
 		CompletedTaskIDs: []string{},
 		LastCheck: &types.CheckResult{
 			Status:    types.CheckFail,
 			Timestamp: time.Now(),
 		},
-		QuestStarted: false,
+		QuestStarted:   false,
+		SnapshotModule: snapshotModule,
+		Workspace:      workspace,
 	}
 
 	err = UploadStateAndPlan(firstState, plan)
 	if err != nil {
 		return err
 	}
+	logEvent(Event{Type: EventBegin, Journey: plan.Journey.Name, Total: plan.NumberOfTasks})
 
 	// Show quest ready message
 	format.Newline()
@@ -189,6 +213,9 @@ func runBegin() error {
 		fmt.Sprintf("  %s• %s%s", format.ColorCyan, PlanFilePath, format.ColorReset),
 		fmt.Sprintf("  %s• %s%s", format.ColorCyan, StateFilePath, format.ColorReset),
 	}
+	if workspace != "" {
+		lines = append(lines, "", fmt.Sprintf("%sYour code goes in %s%s", format.ColorDim, workspace, format.ColorReset))
+	}
 
 	format.Box("Quest Ready", lines)
 	format.CommandHint("Ready to start? Run", "quest next")
@@ -196,21 +223,35 @@ func runBegin() error {
 	return nil
 }
 
-func RunNext(cmd *cobra.Command, args []string) {
+func RunNext(cmd *cobra.Command, args []string) (err error) {
+	report := newReport(cmd, "next")
+	defer func() { err = report.finish(err) }()
+
+	unlock, err := lockForUpdate()
+	if err != nil {
+		return commandError(err)
+	}
+	defer unlock()
+
 	state, plan, err := LoadStateAndPlan()
 	if err != nil {
 		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
-		return
+		return commandError(err)
 	}
 
-	if state.QuestStarted && state.LastCheck != nil && state.LastCheck.Status != types.CheckPass {
+	force, _ := cmd.Flags().GetBool("force")
+	if !force && state.QuestStarted && state.LastCheck != nil && state.LastCheck.Status != types.CheckPass {
 		format.Warning("The previous check didn't pass or didn't happen.")
+		if !prompt.Interactive() || report.enabled {
+			format.CommandHint("To move on anyway without a prompt, run", "quest next --force")
+			return exitWith(ExitFail, errors.New("previous check didn't pass"))
+		}
 		format.Print("Are you sure you want to continue without passing the check? (y/N): ")
 		var response string
 		fmt.Scanln(&response)
 		if response != "y" && response != "Y" {
 			format.Info("Run 'quest check' to validate your progress first.")
-			return
+			return exitWith(ExitFail, errors.New("previous check didn't pass"))
 		}
 		format.Info("Continuing without check validation...")
 	}
@@ -225,7 +266,8 @@ func RunNext(cmd *cobra.Command, args []string) {
 	// Check bounds after increment
 	if state.CurrentTaskIndex >= plan.NumberOfTasks {
 		format.Success("You've reached the final task! Run 'quest complete' when done.")
-		return
+		report.Progress = progressReport(plan, state)
+		return nil
 	}
 
 	tasks := FlattenTasks(plan)
@@ -235,19 +277,27 @@ func RunNext(cmd *cobra.Command, args []string) {
 		err := createArtifact(file)
 		if err != nil {
 			format.ErrorWithTip("Error creating artifact", err, fmt.Sprintf("Check permissions for %s", file))
-			return
+			return exitWith(ExitInternal, err)
 		}
 	}
+	snapshotTask(state, currentTask, SnapshotStart)
 
 	state.LastCheck = &types.CheckResult{
 		Status:    types.CheckFail,
 		Timestamp: time.Now(),
 	}
+	markTaskStarted(state)
+	trackShown(state, currentTask.ID)
 	err = UploadState(state)
 	if err != nil {
 		format.ErrorWithTip("Failed to save state", err, "Check folder permissions")
-		return
+		return exitWith(ExitInternal, err)
 	}
+	logEvent(Event{Type: EventNext, Task: state.CurrentTaskIndex + 1, TaskID: currentTask.ID})
+
+	taskInfo := taskReport(state.CurrentTaskIndex, currentTask, state)
+	report.Task = &taskInfo
+	report.Progress = progressReport(plan, state)
 
 	// Display the new task
 	format.TaskHeader(state.CurrentTaskIndex+1, currentTask.Title)
@@ -272,20 +322,60 @@ func RunNext(cmd *cobra.Command, args []string) {
 	}
 
 	format.CommandHint("When ready, run", "quest check")
+	return nil
 }
 
-func RunCheck(cmd *cobra.Command, args []string) {
+func RunCheck(cmd *cobra.Command, args []string) (err error) {
+	report := newReport(cmd, "check")
+	defer func() { err = report.finish(err) }()
+
+	reportFlags, _ := cmd.Flags().GetStringArray("report")
+	reporters, err := parseReporters(reportFlags)
+	if err != nil {
+		format.ErrorWithTip("Invalid report", err, "Use --report junit=path or --report sarif=path")
+		return exitWith(ExitInternal, err)
+	}
+
+	unlock, err := lockForUpdate()
+	if err != nil {
+		return commandError(err)
+	}
+	defer unlock()
+
 	state, plan, err := LoadStateAndPlan()
 	if err != nil {
 		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
-		return
+		return commandError(err)
+	}
+	if update, _ := cmd.Flags().GetBool("update-goldens"); update {
+		return runGoldenUpdate(cmd, report, state, plan)
+	}
+	defer func() {
+		if errors.Is(err, errCancelled) {
+			return
+		}
+		if writeErr := writeReporters(reporters, report); writeErr != nil {
+			format.ErrorWithTip("Failed to write report", writeErr, "Check the report path and its permissions")
+			err = exitWith(ExitInternal, writeErr)
+		}
+	}()
+	defer func() { report.Progress = progressReport(plan, state) }()
+
+	if all, _ := cmd.Flags().GetBool("all"); all {
+		return runRegressionCheck(cmd, report, state, plan)
+	}
+	taskFlag, _ := cmd.Flags().GetString("task")
+	chapterFlag, _ := cmd.Flags().GetString("chapter")
+	if taskFlag != "" || chapterFlag != "" {
+		return runSelectedCheck(cmd, report, state, plan, taskFlag, chapterFlag)
 	}
+
 	tasks := FlattenTasks(plan)
 
 	// Bounds check for safety
 	if state.CurrentTaskIndex >= len(tasks) {
 		format.Error("Invalid task index", fmt.Errorf("index out of bounds"))
-		return
+		return exitWith(ExitInternal, errors.New("task index out of bounds"))
 	}
 
 	currentTask := tasks[state.CurrentTaskIndex]
@@ -297,62 +387,23 @@ func RunCheck(cmd *cobra.Command, args []string) {
 		TaskID:    state.CurrentTaskIndex,
 		Timestamp: time.Now(),
 	}
-	passState := types.Pass
-	failState := types.Fail
+	opts := checkOptions(cmd, state)
+	defer opts.Workspace.Close()
+	holdLockForCheck(cmd, []types.Task{currentTask}, opts)
+	ctx, cancel := checkContext(cmd)
+	defer cancel()
 
-	passedCount := 0
-	failedCount := 0
+	started := time.Now()
+	passedCount, failedCount, results := checkTask(ctx, &currentTask, opts, true)
+	duration := time.Since(started)
 
-	for i, rule := range currentTaskValidation.Rules {
-		check, err := CheckRule(rule)
-		if check {
-			currentTaskValidation.Rules[i].LastState = &passState
-			passedCount++
-
-			// Show success with reason
-			ruleName := rule.Name
-			if ruleName == "" {
-				ruleName = fmt.Sprintf("Rule %d", i+1)
-			}
-
-			// Build success message based on rule type
-			successReason := ""
-			switch rule.Type {
-			case types.TypeExists:
-				successReason = fmt.Sprintf("- found '%s'", rule.Path)
-			case types.TypeGlobCountMin:
-				count, _ := CountFilesMatching(rule.Glob)
-				successReason = fmt.Sprintf("- found %d file(s) matching '%s'", count, rule.Glob)
-			case types.TypeFileContainsAny:
-				if len(rule.Any) == 1 {
-					successReason = fmt.Sprintf("- %s - contains '%s'", rule.Glob, rule.Any[0])
-				} else {
-					successReason = fmt.Sprintf("- %s - contains required '%v'", rule.Glob, rule.Any)
-				}
-			}
-
-			format.CheckPass(ruleName, successReason)
-		} else {
-			failedCount++
-
-			currentTaskValidation.Rules[i].LastState = &failState
-
-			// Show failure - name + reason
-			ruleName := rule.Name
-			if ruleName == "" {
-				ruleName = fmt.Sprintf("Rule %d", i+1)
-			}
-
-			// Show the failure reason on the same line
-			failureReason := ""
-			if err != nil {
-				failureReason = err.Error()
-			} else if rule.Description != "" {
-				failureReason = rule.Description
-			}
-
-			format.CheckFail(ruleName, failureReason)
-		}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		format.Newline()
+		format.Warning("Check cancelled, nothing was saved")
+		return exitWith(ExitFail, errCancelled)
+	}
+	if err := opts.Cache.Save(); err != nil {
+		format.Warning(fmt.Sprintf("Could not save the check cache: %v", err))
 	}
 
 	// Set final status
@@ -366,6 +417,8 @@ func RunCheck(cmd *cobra.Command, args []string) {
 		if !contains(state.CompletedTaskIDs, taskID) {
 			state.CompletedTaskIDs = append(state.CompletedTaskIDs, taskID)
 		}
+		state.RegressedTaskIDs = remove(state.RegressedTaskIDs, taskID)
+		snapshotTask(state, currentTask, SnapshotPass)
 
 		format.CommandHint("Ready for next task? Run", "quest next")
 		format.CommandHint("To Run an AI check", "quest check --annotate")
@@ -381,7 +434,7 @@ func RunCheck(cmd *cobra.Command, args []string) {
 	if annotate {
 		workDir, err := os.Getwd()
 		if err == nil {
-			fmt.Println()
+			format.Newline()
 
 			// Collect files to analyze
 			var filesToAnalyze []string
@@ -408,30 +461,18 @@ func RunCheck(cmd *cobra.Command, args []string) {
 				if err != nil {
 					format.Warning("Failed to generate annotations: " + err.Error())
 				} else if len(annotations) > 0 {
+					snapshotTask(state, currentTask, SnapshotAI)
 					err = copilot_helper.ApplyCheckAnnotations(annotations, workDir)
 					if err != nil {
 						format.Warning("Failed to add annotations: " + err.Error())
 					} else {
 						format.AnnotationSummary(len(annotations))
 
-						issueLevel := 0
-						for _, ann := range annotations {
-							switch ann.Type {
-							case "error":
-								lastCheck.Status = types.CheckFail
-							case "warning":
-								if issueLevel < 2 {
-									lastCheck.Status = types.CheckWarn
-								}
-							case "info":
-								if issueLevel < 1 {
-									lastCheck.Status = types.CheckPass
-								}
-							}
-						}
+						lastCheck.Status = annotatedStatus(lastCheck.Status, annotations)
 						// Show what was added
 						for _, ann := range annotations {
 							format.FileLocation(ann.File, ann.Line)
+							report.Hints = append(report.Hints, HintReport{File: ann.File, Line: ann.Line, Type: ann.Type, Comment: ann.Comment})
 						}
 						format.Newline()
 					}
@@ -443,12 +484,149 @@ func RunCheck(cmd *cobra.Command, args []string) {
 	}
 
 	state.LastCheck = lastCheck
+	trackCheck(state, currentTask.ID, failedCount == 0)
+
+	taskInfo := taskReport(state.CurrentTaskIndex, currentTask, state)
+	taskInfo.Check = taskCheck(passedCount, failedCount, results)
+	taskInfo.Check.Status = lastCheck.Status
+	report.Task = &taskInfo
 
 	err = UploadStateAndPlan(state, plan)
 	if err != nil {
 		format.ErrorWithTip("Failed to save quest data", err, "Check folder permissions")
-		return
+		return exitWith(ExitInternal, err)
+	}
+	event := checkEvent(SourceCheck, state.CurrentTaskIndex, currentTask, lastCheck.Status, duration, results)
+	event.Hints = report.Hints
+	logEvent(event)
+
+	switch {
+	case failedCount > 0 || lastCheck.Status == types.CheckFail:
+		return exitWith(ExitFail, errChecksFailed)
+	case lastCheck.Status == types.CheckWarn:
+		return exitWith(ExitWarn, errChecksWarned)
+	}
+	return nil
+}
+
+// annotatedStatus raises the status of a check by the severity of its AI
+// annotations: an error fails it and a warning turns a pass into a warning.
+// Annotations never lower it, failing rules fail the check whatever they say.
+func annotatedStatus(status types.CheckStatus, annotations []copilot_helper.CheckAnnotation) types.CheckStatus {
+	for _, ann := range annotations {
+		switch {
+		case ann.Type == "error":
+			status = types.CheckFail
+		case ann.Type == "warning" && status == types.CheckPass:
+			status = types.CheckWarn
+		}
+	}
+	return status
+}
+
+// checkTask evaluates and prints the rules of one task, recording each
+// rule's LastState, and returns every reported result. Git rules are skipped
+// unless includeGit is set since they only describe the task in progress.
+func checkTask(ctx context.Context, task *types.Task, opts CheckOptions, includeGit bool) (passedCount, failedCount int, reported []RuleResult) {
+	passState := types.Pass
+	failState := types.Fail
+
+	var rules []types.Rule
+	var ruleIndexes []int
+	for i, rule := range task.Validation.Rules {
+		if includeGit || !isGitRule(rule) {
+			rules = append(rules, rule)
+			ruleIndexes = append(ruleIndexes, i)
+		}
+	}
+
+	EvaluateRules(ctx, rules, opts, func(i int, results []RuleResult) {
+		rule := &task.Validation.Rules[ruleIndexes[i]]
+		rule.LastState = &passState
+		reported = append(reported, results...)
+		for _, result := range results {
+			if result.Cached {
+				result.Reason += " (cached)"
+			}
+			if result.Passed {
+				passedCount++
+				format.CheckPass(result.Name, result.Reason)
+			} else {
+				failedCount++
+				rule.LastState = &failState
+				format.CheckFail(result.Name, result.Reason)
+			}
+			if result.Detail != "" {
+				format.CheckDetail(result.Detail)
+			}
+		}
+	})
+
+	if skipped := len(task.Validation.Rules) - len(rules); skipped > 0 {
+		format.Dim(fmt.Sprintf("Skipped %d git rule(s), they only apply to the current task", skipped))
 	}
+	return passedCount, failedCount, reported
+}
+
+// recordCheck stores the outcome of checking the task at index as the last
+// check, records the task as completed when it passed and counts the attempt
+func recordCheck(state *types.State, index int, task types.Task, passed bool) {
+	lastCheck := &types.CheckResult{
+		TaskID:    index,
+		Status:    types.CheckFail,
+		Timestamp: time.Now(),
+		Message:   "Some validations failed",
+	}
+	if passed {
+		lastCheck.Status = types.CheckPass
+		lastCheck.Message = "All checks passed"
+		if !contains(state.CompletedTaskIDs, task.ID) {
+			state.CompletedTaskIDs = append(state.CompletedTaskIDs, task.ID)
+		}
+		state.RegressedTaskIDs = remove(state.RegressedTaskIDs, task.ID)
+		snapshotTask(state, task, SnapshotPass)
+	}
+	state.LastCheck = lastCheck
+	trackCheck(state, task.ID, passed)
+}
+
+// checkOptions reads the evaluation flags shared by every check mode. Callers
+// close the returned Workspace once the check is done.
+func checkOptions(cmd *cobra.Command, state *types.State) CheckOptions {
+	updateGoldens, _ := cmd.Flags().GetBool("update-goldens")
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	noCache, _ := cmd.Flags().GetBool("no-cache")
+	return CheckOptions{
+		UpdateGoldens:   updateGoldens,
+		Jobs:            jobs,
+		Cache:           LoadRuleCache(),
+		NoCache:         noCache,
+		TaskStartedAt:   state.TaskStartedAt,
+		TaskStartCommit: state.TaskStartCommit,
+		Workspace:       &workspaceCopy{},
+	}
+}
+
+// checkContext is cancelled by Ctrl-C, which stops every running rule and
+// kills what they started, or when the --timeout for the whole check passes
+func checkContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// holdLockForCheck lets commands waiting for the quest know that checking
+// tasks may keep it for much longer than a quick update
+func holdLockForCheck(cmd *cobra.Command, tasks []types.Task, opts CheckOptions) {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	holdLockFor(checkDuration(timeout, tasks, opts))
 }
 
 func contains(slice []string, item string) bool {
@@ -460,6 +638,17 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
+// remove returns slice without any occurrence of item
+func remove(slice []string, item string) []string {
+	kept := []string{}
+	for _, s := range slice {
+		if s != item {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
 // CountFilesMatching returns the number of files matching a glob pattern
 func CountFilesMatching(pattern string) (int, error) {
 	matches, err := filepath.Glob(pattern)
@@ -469,11 +658,17 @@ func CountFilesMatching(pattern string) (int, error) {
 	return len(matches), nil
 }
 
-func RunCompete(cmd *cobra.Command, args []string) {
+func RunCompete(cmd *cobra.Command, args []string) error {
+	unlock, err := lockForUpdate()
+	if err != nil {
+		return commandError(err)
+	}
+	defer unlock()
+
 	state, plan, err := LoadStateAndPlan()
 	if err != nil {
 		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
-		return
+		return commandError(err)
 	}
 
 	// Count completed vs total tasks
@@ -482,30 +677,49 @@ func RunCompete(cmd *cobra.Command, args []string) {
 	allCompleted := ifCompletedPlan(*plan, *state)
 
 	// Confirm with user
-	confirmed, cancelled, err := prompt.ConfirmComplete(completedTasks, totalTasks, allCompleted)
-	if err != nil {
-		format.Error("Error reading input", err)
-		return
-	}
+	if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+		if !prompt.Interactive() {
+			format.ErrorWithTip("Completing a quest needs confirmation", nil, "Run 'quest complete --yes' to skip the prompt")
+			return exitWith(ExitFail, errors.New("not confirmed"))
+		}
+		confirmed, cancelled, err := prompt.ConfirmComplete(completedTasks, totalTasks, allCompleted)
+		if err != nil {
+			format.Error("Error reading input", err)
+			return exitWith(ExitInternal, err)
+		}
 
-	if cancelled || !confirmed {
-		format.Warning("Quest completion cancelled")
-		return
+		if cancelled || !confirmed {
+			format.Warning("Quest completion cancelled")
+			return exitWith(ExitFail, errCancelled)
+		}
 	}
 
-	// User confirmed - remove quest folder
-	err = os.RemoveAll(QuestFolderName)
+	logEvent(Event{Type: EventComplete, Journey: plan.Journey.Name, Completed: completedTasks, Total: totalTasks})
+
+	// User confirmed - archive the quest, and remove .quest once no quest is left
+	archive, err := archiveQuest(plan, state)
 	if err != nil {
-		format.ErrorWithTip("Failed to remove .quest folder", err, "Try manually deleting the .quest folder")
-		return
+		format.ErrorWithTip("Failed to archive the quest", err, fmt.Sprintf("Your quest is untouched in %s", QuestDir))
+		return exitWith(ExitInternal, err)
+	}
+	// The lock moved along with the quest and means nothing in the archive
+	os.Remove(filepath.Join(archive.Path, filepath.Base(LockFilePath)))
+	if CurrentQuest() == QuestName {
+		os.Remove(CurrentQuestFilePath)
+	}
+	if remaining, _ := ListQuests(); len(remaining) == 0 {
+		os.RemoveAll(QuestFolderPath)
 	}
 
 	if allCompleted {
-		format.Success("🎉 Quest completed! All files cleaned up")
+		format.Success("🎉 Quest completed!")
 	} else {
-		format.Success("Quest marked as complete. Files cleaned up")
+		format.Success("Quest marked as complete")
 		format.Printf("You completed %d of %d tasks.\n\n", completedTasks, totalTasks)
 	}
+	format.Dim(fmt.Sprintf("Archived to %s", archive.Path))
+	format.CommandHint("Look back on it with", "quest history")
+	return nil
 }
 
 func countTasks(plan types.Plan) int {
@@ -547,11 +761,20 @@ func selectTemplateForSpec(spec *types.ProjectSpec) string {
 	}
 }
 
-func RunJumpTo(cmd *cobra.Command, args []string) {
+func RunJumpTo(cmd *cobra.Command, args []string) (err error) {
+	report := newReport(cmd, "jumpTo")
+	defer func() { err = report.finish(err) }()
+
+	unlock, err := lockForUpdate()
+	if err != nil {
+		return commandError(err)
+	}
+	defer unlock()
+
 	state, plan, err := LoadStateAndPlan()
 	if err != nil {
 		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
-		return
+		return commandError(err)
 	}
 
 	var taskIndex int
@@ -561,7 +784,7 @@ func RunJumpTo(cmd *cobra.Command, args []string) {
 		// Find the last completed task index
 		if len(state.CompletedTaskIDs) == 0 {
 			format.Warning("No completed tasks yet")
-			return
+			return exitWith(ExitFail, errors.New("no completed tasks"))
 		}
 
 		// Find highest completed task index
@@ -575,20 +798,20 @@ func RunJumpTo(cmd *cobra.Command, args []string) {
 
 		if maxCompletedIndex < 0 {
 			format.Warning("Could not find last completed task")
-			return
+			return exitWith(ExitFail, errors.New("no completed tasks"))
 		}
 		taskIndex = maxCompletedIndex
 	} else {
 		// Parse task index from args
 		if len(args) == 0 {
 			format.ErrorWithTip("Missing task index", nil, "Usage: quest jump-to <task-number>")
-			return
+			return exitWith(ExitInternal, errors.New("missing task index"))
 		}
 
 		taskNum, err := strconv.Atoi(args[0])
 		if err != nil {
 			format.ErrorWithTip("Invalid task index", err, "Task index must be a number")
-			return
+			return exitWith(ExitInternal, err)
 		}
 
 		// Convert from 1-based to 0-based index
@@ -598,66 +821,106 @@ func RunJumpTo(cmd *cobra.Command, args []string) {
 	// Validate bounds
 	if taskIndex < 0 || taskIndex >= plan.NumberOfTasks {
 		format.Error(fmt.Sprintf("Task index out of range (1-%d)", plan.NumberOfTasks), nil)
-		return
+		return exitWith(ExitInternal, errors.New("task index out of range"))
 	}
 
+	allTasks := FlattenTasks(plan)
+	currentTask := allTasks[taskIndex]
+
 	// Update state
+	from := state.CurrentTaskIndex
 	state.CurrentTaskIndex = taskIndex
 	state.ExplainCount = 0
+	markTaskStarted(state)
+	trackShown(state, currentTask.ID)
+	// quest reset goes back to how the files were when the task was first taken up
+	if _, err := snapshotFor(currentTask.ID, SnapshotStart); errors.Is(err, os.ErrNotExist) {
+		snapshotTask(state, currentTask, SnapshotStart)
+	}
 
 	// Save state
 	err = UploadState(state)
 	if err != nil {
 		format.ErrorWithTip("Failed to save state", err, "Changes not persisted")
-		return
+		return exitWith(ExitInternal, err)
 	}
 
 	// Show confirmation
-	allTasks := FlattenTasks(plan)
-	currentTask := allTasks[taskIndex]
+	logEvent(Event{Type: EventJumpTo, Task: taskIndex + 1, TaskID: currentTask.ID, From: from + 1})
+	taskInfo := taskReport(taskIndex, currentTask, state)
+	report.Task = &taskInfo
+	report.Progress = progressReport(plan, state)
 	format.Success(fmt.Sprintf("Jumped to Task %d: %s", taskIndex+1, currentTask.Title))
 	format.CommandHint("View task details with", "quest next")
+	return nil
 }
 
-func RunSummary(cmd *cobra.Command, args []string) {
+func RunSummary(cmd *cobra.Command, args []string) (err error) {
+	report := newReport(cmd, "summary")
+	defer func() { err = report.finish(err) }()
+
 	state, plan, err := LoadStateAndPlan()
 	if err != nil {
 		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
-		return
+		return commandError(err)
 	}
+	report.Progress = summaryReport(plan, state)
 	printSummary(*plan, *state)
+	return nil
 }
 
-func RunHealthCheck(cmd *cobra.Command, args []string) {
-	format.Header("Quest Health Check")
-	format.Newline()
+func RunHealthCheck(cmd *cobra.Command, args []string) (err error) {
+	report := newReport(cmd, "health")
+	defer func() { err = report.finish(err) }()
 
 	allHealthy := true
+	warnings := 0
+	healthCheck := func(name string, passed bool, reason string) {
+		report.Health = append(report.Health, RuleResult{Name: name, Passed: passed, Reason: reason})
+		if passed {
+			format.CheckPass(name, reason)
+		} else {
+			format.CheckFail(name, reason)
+		}
+	}
+	// Environment checks only warn, the report lists them as failed
+	environmentCheck := func(name string, passed bool, reason string) {
+		report.Health = append(report.Health, RuleResult{Name: name, Passed: passed, Reason: reason})
+		if passed {
+			format.Info("✓ " + reason)
+		} else {
+			format.Warning("⚠ " + reason)
+			warnings++
+		}
+	}
+
+	format.Header("Quest Health Check")
+	format.Newline()
 
 	// Check .quest folder
-	questFolder, err := checkExistenceOfFile(QuestFolderName)
+	questFolder, err := checkExistenceOfFile(QuestDir)
 	if questFolder {
-		format.CheckPass(".quest folder", "exists")
+		healthCheck(".quest folder", true, fmt.Sprintf("%s exists", QuestDir))
 	} else {
-		format.CheckFail(".quest folder", "not found - run 'quest begin' to start")
+		healthCheck(".quest folder", false, fmt.Sprintf("%s not found - run 'quest begin' to start", QuestDir))
 		allHealthy = false
 	}
 
 	// Check plan file
 	planFile, err := checkExistenceOfFile(PlanFilePath)
 	if planFile {
-		format.CheckPass("plan.json", "exists")
+		healthCheck("plan.json", true, "exists")
 	} else {
-		format.CheckFail("plan.json", "not found")
+		healthCheck("plan.json", false, "not found")
 		allHealthy = false
 	}
 
 	// Check state file
 	stateFile, err := checkExistenceOfFile(StateFilePath)
 	if stateFile {
-		format.CheckPass("state.json", "exists")
+		healthCheck("state.json", true, "exists")
 	} else {
-		format.CheckFail("state.json", "not found")
+		healthCheck("state.json", false, "not found")
 		allHealthy = false
 	}
 
@@ -665,12 +928,12 @@ func RunHealthCheck(cmd *cobra.Command, args []string) {
 	plan, err := LoadPlan()
 	planLoad := plan != nil
 	if err != nil {
-		format.CheckFail("plan loading", fmt.Sprintf("failed to load: %s", err.Error()))
+		healthCheck("plan loading", false, fmt.Sprintf("failed to load: %s", err.Error()))
 		allHealthy = false
 	} else if planLoad {
-		format.CheckPass("plan loading", fmt.Sprintf("loaded successfully (%d tasks)", plan.NumberOfTasks))
+		healthCheck("plan loading", true, fmt.Sprintf("loaded successfully (%d tasks)", plan.NumberOfTasks))
 	} else {
-		format.CheckFail("plan loading", "plan is nil")
+		healthCheck("plan loading", false, "plan is nil")
 		allHealthy = false
 	}
 
@@ -678,12 +941,12 @@ func RunHealthCheck(cmd *cobra.Command, args []string) {
 	state, err := LoadState()
 	stateLoad := state != nil
 	if err != nil {
-		format.CheckFail("state loading", fmt.Sprintf("failed to load: %s", err.Error()))
+		healthCheck("state loading", false, fmt.Sprintf("failed to load: %s", err.Error()))
 		allHealthy = false
 	} else if stateLoad {
-		format.CheckPass("state loading", fmt.Sprintf("loaded successfully (task %d/%d)", state.CurrentTaskIndex+1, plan.NumberOfTasks))
+		healthCheck("state loading", true, fmt.Sprintf("loaded successfully (task %d/%d)", state.CurrentTaskIndex+1, plan.NumberOfTasks))
 	} else {
-		format.CheckFail("state loading", "state is nil")
+		healthCheck("state loading", false, "state is nil")
 		allHealthy = false
 	}
 
@@ -696,20 +959,18 @@ func RunHealthCheck(cmd *cobra.Command, args []string) {
 	format.Newline()
 
 	// Check if in a git repository
-	_, err = os.Stat(".git")
-	if err != nil {
-		format.Warning("⚠ Not in a git repository - version control recommended")
+	if _, err := os.Stat(".git"); err != nil {
+		environmentCheck("git repository", false, "Not in a git repository - version control recommended")
 	} else {
-		format.Info("✓ Git repository detected")
+		environmentCheck("git repository", true, "Git repository detected")
 	}
 
 	// Check if GitHub Copilot CLI is available
-	_, err = os.Stat(os.ExpandEnv("$HOME/.copilot"))
-	if err != nil {
+	if _, err := os.Stat(os.ExpandEnv("$HOME/.copilot")); err != nil {
 		// Try alternative check via command
-		format.Warning("⚠ GitHub Copilot CLI not detected - enhanced features may be limited")
+		environmentCheck("copilot", false, "GitHub Copilot CLI not detected - enhanced features may be limited")
 	} else {
-		format.Info("✓ GitHub Copilot CLI detected")
+		environmentCheck("copilot", true, "GitHub Copilot CLI detected")
 	}
 
 	format.Newline()
@@ -724,6 +985,16 @@ func RunHealthCheck(cmd *cobra.Command, args []string) {
 		format.Error("❌ Some health checks failed", nil)
 		format.CommandHint("Try running", "quest begin")
 	}
+
+	switch {
+	case !questFolder:
+		return exitWith(ExitNotInitialized, errors.New("no quest in this directory"))
+	case !allHealthy:
+		return exitWith(ExitFail, errors.New("health checks failed"))
+	case warnings > 0:
+		return exitWith(ExitWarn, errors.New("health checks passed with warnings"))
+	}
+	return nil
 }
 
 func printSummary(plan types.Plan, state types.State) {
@@ -772,14 +1043,20 @@ func printSummary(plan types.Plan, state types.State) {
 				}
 
 				allChapterComplete := true
+				chapterRegressed := false
 				for _, task := range chapterTasks {
 					if !contains(state.CompletedTaskIDs, task.ID) {
 						allChapterComplete = false
-						break
+					}
+					if contains(state.RegressedTaskIDs, task.ID) {
+						chapterRegressed = true
 					}
 				}
 
-				if allChapterComplete {
+				if chapterRegressed {
+					format.Line(fmt.Sprintf("  %s⚠%s Chapter %d: %s %s(regressed)%s",
+						format.ColorYellow, format.ColorReset, chIdx+1, ch.Title, format.ColorYellow, format.ColorReset))
+				} else if allChapterComplete {
 					format.Line(fmt.Sprintf("  %s✔%s Chapter %d: %s",
 						format.ColorGreen, format.ColorReset, chIdx+1, ch.Title))
 				} else if chIdx == currentChapter {
@@ -804,7 +1081,11 @@ func printSummary(plan types.Plan, state types.State) {
 		// Show all tasks in current quest with status
 		for _, task := range quest.Tasks {
 			taskID := task.ID
-			if contains(state.CompletedTaskIDs, taskID) {
+			if contains(state.RegressedTaskIDs, taskID) {
+				// Passed once, fails now
+				format.Line(fmt.Sprintf("      %s⚠%s %s %s(regressed)%s",
+					format.ColorYellow, format.ColorReset, task.Title, format.ColorYellow, format.ColorReset))
+			} else if contains(state.CompletedTaskIDs, taskID) {
 				// Completed task
 				format.Line(fmt.Sprintf("      %s✔%s %s",
 					format.ColorGreen, format.ColorReset, task.Title))
@@ -828,6 +1109,10 @@ func printSummary(plan types.Plan, state types.State) {
 	completedCount := len(state.CompletedTaskIDs)
 	format.Line(fmt.Sprintf("%sCompleted: %d / %d tasks%s",
 		format.ColorDim, completedCount, plan.NumberOfTasks, format.ColorReset))
+	if len(state.RegressedTaskIDs) > 0 {
+		format.Line(fmt.Sprintf("%s⚠ Regressed: %d task(s) that passed before fail now%s, re-check with %squest check --all%s",
+			format.ColorYellow, len(state.RegressedTaskIDs), format.ColorReset, format.ColorCyan, format.ColorReset))
+	}
 
 	if !ifCompleted {
 		format.Line(fmt.Sprintf("%sNext action:%s Run %squest check%s",
@@ -894,14 +1179,25 @@ func taskPerQuest(plan types.Plan) []int {
 	return taskPerQuest
 }
 
-func RunExplain(cmd *cobra.Command, args []string) {
-	err := runExplain()
+func RunExplain(cmd *cobra.Command, args []string) (err error) {
+	report := newReport(cmd, "explain")
+	defer func() { err = report.finish(err) }()
+
+	unlock, err := lockForUpdate()
+	if err != nil {
+		return commandError(err)
+	}
+	defer unlock()
+
+	err = runExplain(report)
 	if err != nil {
 		format.Error("Unable to run explain", err)
+		return commandError(err)
 	}
+	return nil
 }
 
-func runExplain() error {
+func runExplain(report *Report) error {
 	// Load current state
 	state, err := LoadState()
 	if err != nil {
@@ -925,9 +1221,13 @@ func runExplain() error {
 	}
 
 	currentTask := tasks[state.CurrentTaskIndex]
+	taskInfo := taskReport(state.CurrentTaskIndex, currentTask, state)
+	report.Task = &taskInfo
+	report.Progress = progressReport(plan, state)
 
 	// Increment explain count for current task
 	state.ExplainCount++
+	trackHint(state, currentTask.ID)
 
 	// Show context based on attempt count
 	format.ExplainHeader(state.ExplainCount, currentTask.Title)
@@ -975,10 +1275,12 @@ func runExplain() error {
 			format.ColorGreen, format.ColorReset)
 		// Save state even if no hints
 		UploadState(state)
+		logEvent(Event{Type: EventExplain, Task: state.CurrentTaskIndex + 1, TaskID: currentTask.ID})
 		return nil
 	}
 
 	// Apply hints
+	snapshotTask(state, currentTask, SnapshotAI)
 	if err := copilot_helper.ApplyHints(hints, workDir); err != nil {
 		return fmt.Errorf("failed to apply hints: %w", err)
 	}
@@ -987,6 +1289,7 @@ func runExplain() error {
 	format.HintSummary(len(hints))
 
 	for _, hint := range hints {
+		report.Hints = append(report.Hints, HintReport{File: hint.File, Line: hint.Line, Comment: hint.Comment})
 		format.Printf("    %s%s:%d%s - %s\n",
 			format.ColorDim, hint.File, hint.Line, format.ColorReset, hint.Comment)
 	}
@@ -997,6 +1300,7 @@ func runExplain() error {
 	if err := UploadState(state); err != nil {
 		format.Warning("Failed to save state")
 	}
+	logEvent(Event{Type: EventExplain, Task: state.CurrentTaskIndex + 1, TaskID: currentTask.ID, Hints: report.Hints})
 
 	return nil
 }
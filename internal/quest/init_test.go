@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	copilot_helper "github.com/jovanpet/quest/internal/copilot"
 	"github.com/jovanpet/quest/internal/types"
 )
 
@@ -773,3 +774,33 @@ func TestTaskPerQuest(t *testing.T) {
 		})
 	}
 }
+
+func TestAnnotatedStatus(t *testing.T) {
+	annotations := func(kinds ...string) []copilot_helper.CheckAnnotation {
+		var result []copilot_helper.CheckAnnotation
+		for _, kind := range kinds {
+			result = append(result, copilot_helper.CheckAnnotation{Type: kind})
+		}
+		return result
+	}
+	tests := []struct {
+		name        string
+		status      types.CheckStatus
+		annotations []copilot_helper.CheckAnnotation
+		expected    types.CheckStatus
+	}{
+		{"info keeps a failure", types.CheckFail, annotations("info", "success"), types.CheckFail},
+		{"warning keeps a failure", types.CheckFail, annotations("warning"), types.CheckFail},
+		{"warning turns a pass into a warning", types.CheckPass, annotations("info", "warning"), types.CheckWarn},
+		{"error fails a pass", types.CheckPass, annotations("error", "info"), types.CheckFail},
+		{"info keeps a pass", types.CheckPass, annotations("info"), types.CheckPass},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := annotatedStatus(tt.status, tt.annotations); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -2,14 +2,22 @@ package quest
 
 import (
 	"encoding/json"
-	"os"
+	"errors"
 
 	"github.com/jovanpet/quest/internal/format"
 	"github.com/jovanpet/quest/internal/types"
 )
 
 func LoadState() (*types.State, error) {
-	data, err := os.ReadFile(StateFilePath)
+	return loadStateFile(StateFilePath)
+}
+
+func LoadPlan() (*types.Plan, error) {
+	return loadPlanFile(PlanFilePath)
+}
+
+func loadStateFile(path string) (*types.State, error) {
+	data, err := loadVersioned(path, stateMigrations)
 	if err != nil {
 		return nil, err
 	}
@@ -22,8 +30,8 @@ func LoadState() (*types.State, error) {
 	return &state, nil
 }
 
-func LoadPlan() (*types.Plan, error) {
-	data, err := os.ReadFile(PlanFilePath)
+func loadPlanFile(path string) (*types.Plan, error) {
+	data, err := loadVersioned(path, planMigrations)
 	if err != nil {
 		return nil, err
 	}
@@ -39,15 +47,23 @@ func LoadPlan() (*types.Plan, error) {
 func LoadStateAndPlan() (*types.State, *types.Plan, error) {
 	state, err := LoadState()
 	if err != nil {
-		format.ErrorWithTip("Failed to load state", err, "Run 'quest begin' to start a new quest")
+		format.ErrorWithTip("Failed to load state", err, loadTip(err))
 		return nil, nil, err
 	}
 
 	plan, err := LoadPlan()
 	if err != nil {
-		format.ErrorWithTip("Failed to load plan", err, "Run 'quest begin' to start a new quest")
+		format.ErrorWithTip("Failed to load plan", err, loadTip(err))
 		return nil, nil, err
 	}
 
 	return state, plan, nil
 }
+
+// loadTip suggests how to get past a failed load
+func loadTip(err error) string {
+	if errors.Is(err, ErrNewerSchema) {
+		return "Upgrade quest to continue this quest"
+	}
+	return "Run 'quest begin' to start a new quest"
+}
@@ -0,0 +1,242 @@
+package quest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+)
+
+// How long a command waits for another one to release .quest, on top of how
+// long the holder said it may need, and how old a lock from another machine
+// must be before it is considered abandoned
+var (
+	lockTimeout  = time.Minute
+	staleLockAge = time.Hour
+)
+
+// lockPollInterval is how often a waiting command tries the lock again
+const lockPollInterval = 100 * time.Millisecond
+
+// errLocked is returned when another command kept .quest locked for longer
+// than lockTimeout
+var errLocked = errors.New("another quest command is using this quest")
+
+// lockInfo is written to the lock file to tell who holds it
+type lockInfo struct {
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	Until      time.Time `json:"until,omitempty"` // set by holders that may keep it long, see holdLockFor
+	Token      string    `json:"token,omitempty"` // tells this holding of the lock from any later one
+}
+
+// writeFileAtomic replaces path with data through a temporary file and a
+// rename, so readers and crashes never see a half-written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LockQuest takes the advisory lock on the selected quest so a
+// load-modify-save cycle can't interleave with another command's. It waits
+// up to lockTimeout for the holder, or that much past the time the holder
+// said it may need, and takes over locks left behind by crashed commands.
+// The returned function releases the lock.
+func LockQuest() (func(), error) {
+	if _, err := os.Stat(QuestDir); err != nil {
+		return nil, err
+	}
+	return lockAt(LockFilePath)
+}
+
+// lockAt takes the lock held in the file path, see LockQuest
+func lockAt(path string) (func(), error) {
+	host, _ := os.Hostname()
+	tokenBytes := make([]byte, 8)
+	rand.Read(tokenBytes)
+	info := lockInfo{PID: os.Getpid(), Host: host, AcquiredAt: time.Now(), Token: hex.EncodeToString(tokenBytes)}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	// A lock taken over or released in the meantime isn't ours to remove
+	unlock := func() {
+		if holder, err := readLockFile(path); err == nil && holder.Token == info.Token {
+			os.Remove(path)
+		}
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	waiting := false
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(data)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return unlock, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		holder, seen, stale := readLock(path)
+		if stale {
+			if seen == nil {
+				// Released in the meantime
+				continue
+			}
+			taken, err := takeOverLock(path, seen, data, info.Token)
+			if err != nil {
+				return nil, err
+			}
+			if taken {
+				return unlock, nil
+			}
+			continue
+		}
+		if until := holder.Until.Add(lockTimeout); until.After(deadline) {
+			deadline = until
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w (pid %d on %s since %s)", errLocked, holder.PID, holder.Host, holder.AcquiredAt.Format("15:04:05"))
+		}
+		if !waiting {
+			format.Dim(fmt.Sprintf("Waiting for another quest command (pid %d) to finish...", holder.PID))
+			waiting = true
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// takeOverLock replaces the stale lock file seen at path with data in one
+// rename, so the lock never goes missing for a third command to create
+// meanwhile. Every command that found the same lock stale may rename over
+// it, so the lock is ours only if it still holds token once they had time to.
+func takeOverLock(path string, seen os.FileInfo, data []byte, token string) (bool, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return false, err
+	}
+
+	// Someone else replaced or released the stale lock first
+	if current, err := os.Stat(path); err != nil || !os.SameFile(current, seen) {
+		return false, nil
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return false, err
+	}
+	time.Sleep(lockPollInterval)
+	holder, err := readLockFile(path)
+	return err == nil && holder.Token == token, nil
+}
+
+// holdLockFor tells commands waiting for the lock this command holds that it
+// may keep it for d, like a check running the learner's code does, so they
+// wait for it instead of giving up after lockTimeout. It does its best, a
+// holder that can't say so only makes waiters give up sooner.
+func holdLockFor(d time.Duration) {
+	held, err := readLockFile(LockFilePath)
+	if err != nil || held.PID != os.Getpid() {
+		return
+	}
+	held.Until = time.Now().Add(d)
+	if data, err := json.Marshal(held); err == nil {
+		writeFileAtomic(LockFilePath, data, 0644)
+	}
+}
+
+// readLockFile reads who holds the lock in path
+func readLockFile(path string) (lockInfo, error) {
+	var holder lockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return holder, err
+	}
+	if err := json.Unmarshal(data, &holder); err != nil {
+		return holder, err
+	}
+	return holder, nil
+}
+
+// readLock returns who holds the lock, the lock file it looked at (nil when
+// the lock was released in the meantime) and whether its holder is gone. A
+// lock of a process that no longer runs on this machine is stale, as is one
+// from another machine older than staleLockAge. A lock that can't be read is
+// only stale once it is a few seconds old, its holder may still be writing.
+func readLock(path string) (lockInfo, os.FileInfo, bool) {
+	var holder lockInfo
+	stat, err := os.Stat(path)
+	if err != nil {
+		// Released in the meantime, try again
+		return holder, nil, errors.Is(err, fs.ErrNotExist)
+	}
+	holder, err = readLockFile(path)
+	if err != nil || holder.PID <= 0 {
+		return holder, stat, time.Since(stat.ModTime()) > 5*time.Second
+	}
+
+	host, _ := os.Hostname()
+	if holder.Host == host {
+		return holder, stat, holder.PID != os.Getpid() && !processAlive(holder.PID)
+	}
+	return holder, stat, time.Since(stat.ModTime()) > staleLockAge
+}
+
+// lockForUpdate takes the quest lock for a command that loads, changes and
+// saves quest data, printing why it couldn't
+func lockForUpdate() (func(), error) {
+	unlock, err := LockQuest()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
+		} else {
+			format.ErrorWithTip("Could not lock the quest", err, fmt.Sprintf("Wait for the other command to finish, or delete %s if it crashed", LockFilePath))
+		}
+		return nil, err
+	}
+	return unlock, nil
+}
@@ -0,0 +1,224 @@
+package quest
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	os.MkdirAll(QuestDir, 0755)
+}
+
+func writeLock(t *testing.T, info lockInfo) {
+	t.Helper()
+	data, _ := json.Marshal(info)
+	if err := os.WriteFile(LockFilePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	chdirTemp(t)
+	os.WriteFile(StateFilePath, []byte("old"), 0644)
+
+	if err := writeFileAtomic(StateFilePath, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(StateFilePath)
+	if string(data) != "new" {
+		t.Errorf("Expected the file to be replaced, got %q", data)
+	}
+	entries, _ := os.ReadDir(QuestDir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files left behind, got %v", entries)
+	}
+	info, _ := os.Stat(StateFilePath)
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 0644, got %v", info.Mode().Perm())
+	}
+}
+
+func TestLockQuest(t *testing.T) {
+	chdirTemp(t)
+	old := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = old })
+
+	unlock, err := LockQuest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LockQuest(); !errors.Is(err, errLocked) {
+		t.Errorf("Expected the second lock to time out, got %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(LockFilePath); !os.IsNotExist(err) {
+		t.Errorf("Expected unlock to remove the lock file, got %v", err)
+	}
+
+	unlock, err = LockQuest()
+	if err != nil {
+		t.Fatalf("Expected the lock to be free again, got %v", err)
+	}
+	unlock()
+}
+
+func TestHoldLockFor(t *testing.T) {
+	chdirTemp(t)
+	old := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = old })
+
+	unlock, err := LockQuest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A check that may take longer than lockTimeout is waited for
+	holdLockFor(400 * time.Millisecond)
+	go func(unlock func()) {
+		time.Sleep(300 * time.Millisecond)
+		unlock()
+	}(unlock)
+	unlock, err = LockQuest()
+	if err != nil {
+		t.Fatalf("Expected to wait for the long holder, got %v", err)
+	}
+	unlock()
+
+	rules := []types.Rule{{Type: types.TypeExists}, {Type: types.TypeCommand, Timeout: "5m"}}
+	tasks := []types.Task{{Validation: types.Validation{Rules: rules}}}
+	if got := checkDuration(0, tasks, CheckOptions{}); got != DefaultRuleTimeout+5*time.Minute {
+		t.Errorf("Expected every rule timing out in turn, got %s", got)
+	}
+	if got := checkDuration(time.Minute, tasks, CheckOptions{}); got != time.Minute {
+		t.Errorf("Expected the check timeout to bound it, got %s", got)
+	}
+}
+
+func TestLockQuestStale(t *testing.T) {
+	chdirTemp(t)
+	old := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = old })
+	host, _ := os.Hostname()
+
+	// A process id far above any pid_max is never alive
+	writeLock(t, lockInfo{PID: 1 << 30, Host: host, AcquiredAt: time.Now()})
+	unlock, err := LockQuest()
+	if err != nil {
+		t.Fatalf("Expected the lock of a dead process to be taken over, got %v", err)
+	}
+	unlock()
+
+	writeLock(t, lockInfo{PID: 1, Host: "elsewhere", AcquiredAt: time.Now()})
+	if _, err := LockQuest(); !errors.Is(err, errLocked) {
+		t.Errorf("Expected a fresh lock from another host to be respected, got %v", err)
+	}
+	past := time.Now().Add(-2 * staleLockAge)
+	os.Chtimes(LockFilePath, past, past)
+	unlock, err = LockQuest()
+	if err != nil {
+		t.Fatalf("Expected an old lock from another host to be taken over, got %v", err)
+	}
+	unlock()
+}
+
+func TestLockQuestTakeOverIsExclusive(t *testing.T) {
+	chdirTemp(t)
+	old := lockTimeout
+	lockTimeout = 10 * time.Second
+	t.Cleanup(func() { lockTimeout = old })
+	host, _ := os.Hostname()
+
+	// Commands finding the same stale lock take turns, never hold it together
+	writeLock(t, lockInfo{PID: 1 << 30, Host: host, AcquiredAt: time.Now()})
+	var mu sync.Mutex
+	holders, most := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := LockQuest()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			holders++
+			if holders > most {
+				most = holders
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			holders--
+			mu.Unlock()
+			unlock()
+		}()
+	}
+	wg.Wait()
+	if most != 1 {
+		t.Errorf("Expected one holder at a time, saw %d", most)
+	}
+
+	// A stale lock replaced by a fresh one before the takeover is kept
+	writeLock(t, lockInfo{PID: 1 << 30, Host: host, AcquiredAt: time.Now()})
+	_, seen, stale := readLock(LockFilePath)
+	if !stale {
+		t.Fatal("Expected the lock of a dead process to be stale")
+	}
+	fresh, _ := json.Marshal(lockInfo{PID: os.Getppid(), Host: host, AcquiredAt: time.Now(), Token: "fresh"})
+	writeFileAtomic(LockFilePath, fresh, 0644)
+	if taken, err := takeOverLock(LockFilePath, seen, []byte(`{"token":"late"}`), "late"); taken || err != nil {
+		t.Errorf("Expected the fresh lock not to be taken over, got %v, %v", taken, err)
+	}
+	if holder, _ := readLockFile(LockFilePath); holder.Token != "fresh" {
+		t.Errorf("Expected the fresh lock to stay, found %+v", holder)
+	}
+}
+
+func TestUnlockKeepsOtherHolders(t *testing.T) {
+	chdirTemp(t)
+	host, _ := os.Hostname()
+
+	unlock, err := LockQuest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another command took the lock over, believing this one was gone
+	writeLock(t, lockInfo{PID: os.Getppid(), Host: host, AcquiredAt: time.Now(), Token: "other"})
+	unlock()
+	if holder, err := readLockFile(LockFilePath); err != nil || holder.Token != "other" {
+		t.Errorf("Expected unlock to leave the other holder's lock, got %+v (%v)", holder, err)
+	}
+}
+
+func TestLockQuestWithoutQuest(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if _, err := LockQuest(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a missing .quest to report not exist, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, QuestFolderName)); !os.IsNotExist(err) {
+		t.Error("Expected no .quest folder to be created")
+	}
+}
@@ -0,0 +1,511 @@
+package quest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	copilot_helper "github.com/jovanpet/quest/internal/copilot"
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/lsp"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// Commands the language server offers as code actions
+const (
+	lspCommandCheck    = "quest.check"
+	lspCommandExplain  = "quest.explain"
+	lspCommandAnnotate = "quest.annotate"
+	lspCommandShowHint = "quest.showHint"
+)
+
+// Diagnostic sources, so editors and code actions can tell feedback apart
+const (
+	lspSourceCheck  = "quest"
+	lspSourceHint   = "quest hint"
+	lspSourceReview = "quest review"
+)
+
+// RunLSP serves check results, AI hints and annotations to editors as
+// diagnostics over the Language Server Protocol on stdin and stdout, without
+// touching the learner's files
+func RunLSP(cmd *cobra.Command, args []string) error {
+	// stdout carries the protocol, nothing else may be printed there
+	format.SetOutput(io.Discard)
+
+	server := lsp.NewServer(lsp.NewConn(os.Stdin, os.Stdout), "quest")
+	ls := newLanguageServer(cmd, server)
+	err := server.Serve(ls)
+	ls.Shutdown()
+	if err != nil {
+		return exitWith(ExitInternal, err)
+	}
+	return nil
+}
+
+// lspClient is what the language server sends to the editor
+type lspClient interface {
+	PublishDiagnostics(path string, diagnostics []lsp.Diagnostic) error
+	ShowMessage(kind lsp.MessageType, message string) error
+	LogMessage(kind lsp.MessageType, message string) error
+}
+
+// languageServer keeps the feedback shown for the current task. Checks and
+// AI requests run in the background; only one check runs at a time and a
+// newer one cancels it.
+type languageServer struct {
+	cmd    *cobra.Command
+	client lspClient
+
+	checkMu sync.Mutex // held while a check runs
+	wg      sync.WaitGroup
+
+	mu          sync.Mutex
+	cancelCheck context.CancelFunc
+	taskIndex   int
+	checks      map[string][]lsp.Diagnostic // path -> diagnostics of the last check
+	hints       map[string][]lsp.Diagnostic // from quest.explain
+	reviews     map[string][]lsp.Diagnostic // from quest.annotate
+	published   map[string]bool
+}
+
+func newLanguageServer(cmd *cobra.Command, client lspClient) *languageServer {
+	return &languageServer{
+		cmd:       cmd,
+		client:    client,
+		taskIndex: -1,
+		published: map[string]bool{},
+	}
+}
+
+// Initialize finds the quest again from the folder the editor opened, the way
+// the CLI does from where it was started: up to the quest root, the quest
+// picked there, then into its workspace, so rule paths resolve like they do
+// in a terminal there
+func (s *languageServer) Initialize(root string) error {
+	if flag, _ := s.cmd.Flags().GetString("root"); root == "" || flag != "" {
+		return nil
+	}
+	if err := os.Chdir(root); err != nil {
+		return err
+	}
+	// The root found where the server was started doesn't apply here
+	setQuestRoot("")
+	if err := EnterQuestRoot(s.cmd); err != nil {
+		return err
+	}
+	if err := SelectQuest(s.cmd); err != nil {
+		return err
+	}
+	EnterWorkspace()
+	return nil
+}
+
+func (s *languageServer) Initialized() {
+	s.recheck()
+}
+
+func (s *languageServer) DidSave(path string) {
+	s.recheck()
+}
+
+func (s *languageServer) Commands() []string {
+	return []string{lspCommandCheck, lspCommandExplain, lspCommandAnnotate, lspCommandShowHint}
+}
+
+func (s *languageServer) Shutdown() {
+	s.mu.Lock()
+	if s.cancelCheck != nil {
+		s.cancelCheck()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// Hover shows the objective and steps of the current task anywhere
+func (s *languageServer) Hover(path string, pos lsp.Position) (*lsp.Hover, error) {
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		return nil, nil
+	}
+	tasks := FlattenTasks(plan)
+	if state.CurrentTaskIndex >= len(tasks) {
+		return nil, nil
+	}
+	return &lsp.Hover{Contents: lsp.MarkupContent{
+		Kind:  "markdown",
+		Value: taskMarkdown(state.CurrentTaskIndex, tasks[state.CurrentTaskIndex]),
+	}}, nil
+}
+
+// taskMarkdown describes a task for hover text
+func taskMarkdown(index int, task types.Task) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Quest Task %d: %s**\n\n%s\n", index+1, task.Title, task.Objective)
+	if len(task.Steps) > 0 {
+		b.WriteString("\n")
+		for i, step := range task.Steps {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+		}
+	}
+	return b.String()
+}
+
+// CodeActions offers to view the hints under the cursor and to ask for new
+// feedback
+func (s *languageServer) CodeActions(path string, params lsp.CodeActionParams) ([]lsp.CodeAction, error) {
+	var actions []lsp.CodeAction
+	for _, d := range params.Context.Diagnostics {
+		title := ""
+		switch d.Source {
+		case lspSourceHint:
+			title = "View quest hint"
+		case lspSourceReview:
+			title = "View quest review comment"
+		case lspSourceCheck:
+			title = "View failed quest check"
+		default:
+			continue
+		}
+		actions = append(actions, lsp.CodeAction{
+			Title:       title,
+			Kind:        "quickfix",
+			Diagnostics: []lsp.Diagnostic{d},
+			Command:     &lsp.Command{Title: title, Command: lspCommandShowHint, Arguments: []interface{}{d.Message}},
+		})
+	}
+	actions = append(actions,
+		lsp.CodeAction{Title: "Quest: check the current task", Command: &lsp.Command{Title: "Check", Command: lspCommandCheck}},
+		lsp.CodeAction{Title: "Quest: get hints for the current task", Command: &lsp.Command{Title: "Explain", Command: lspCommandExplain}},
+		lsp.CodeAction{Title: "Quest: review the current task with AI", Command: &lsp.Command{Title: "Annotate", Command: lspCommandAnnotate}},
+	)
+	return actions, nil
+}
+
+func (s *languageServer) ExecuteCommand(command string, args []json.RawMessage) error {
+	switch command {
+	case lspCommandCheck:
+		s.recheck()
+	case lspCommandExplain:
+		s.background(s.explain)
+	case lspCommandAnnotate:
+		s.background(s.annotate)
+	case lspCommandShowHint:
+		var message string
+		if len(args) == 0 || json.Unmarshal(args[0], &message) != nil {
+			return &lsp.ResponseError{Code: lsp.CodeInvalidParams, Message: "quest.showHint needs the hint text"}
+		}
+		s.client.ShowMessage(lsp.MessageInfo, message)
+	default:
+		return &lsp.ResponseError{Code: lsp.CodeInvalidParams, Message: "unknown command " + command}
+	}
+	return nil
+}
+
+func (s *languageServer) background(run func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		run()
+	}()
+}
+
+// recheck cancels a running check and starts a new one
+func (s *languageServer) recheck() {
+	s.mu.Lock()
+	if s.cancelCheck != nil {
+		s.cancelCheck()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancelCheck = cancel
+	s.mu.Unlock()
+
+	s.background(func() { s.check(ctx) })
+}
+
+// check runs the current task's rules, records the result like quest watch
+// does and publishes the failures
+func (s *languageServer) check(ctx context.Context) {
+	s.checkMu.Lock()
+	defer s.checkMu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	unlock, err := LockQuest()
+	if err != nil {
+		s.client.LogMessage(lsp.MessageError, fmt.Sprintf("quest: failed to lock quest data: %v", err))
+		return
+	}
+	defer unlock()
+
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		s.client.LogMessage(lsp.MessageError, fmt.Sprintf("quest: failed to load quest data: %v", err))
+		return
+	}
+	tasks := FlattenTasks(plan)
+	if !state.QuestStarted || state.CurrentTaskIndex >= len(tasks) {
+		s.setTask(-1)
+		s.setChecks(nil)
+		return
+	}
+	index := state.CurrentTaskIndex
+	task := tasks[index]
+	s.setTask(index)
+
+	opts := checkOptions(s.cmd, state)
+	defer opts.Workspace.Close()
+	holdLockForCheck(s.cmd, []types.Task{task}, opts)
+	checkCtx := ctx
+	if timeout, _ := s.cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		checkCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	passedCount, failedCount, results := checkTask(checkCtx, &task, opts, true)
+	duration := time.Since(started)
+	if ctx.Err() != nil {
+		return
+	}
+	if err := opts.Cache.Save(); err != nil {
+		s.client.LogMessage(lsp.MessageWarning, fmt.Sprintf("quest: could not save the check cache: %v", err))
+	}
+
+	recordCheck(state, index, task, failedCount == 0)
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		s.client.LogMessage(lsp.MessageError, fmt.Sprintf("quest: failed to save quest data: %v", err))
+	} else {
+		logEvent(checkEvent(SourceLSP, index, task, state.LastCheck.Status, duration, results))
+	}
+
+	s.client.LogMessage(lsp.MessageLog, fmt.Sprintf("quest: Task %d: %d passed, %d failed", index+1, passedCount, failedCount))
+	s.setChecks(checkDiagnostics(task, results))
+}
+
+// explain asks for AI hints like quest explain, and shows them as
+// diagnostics instead of writing comments into the files
+func (s *languageServer) explain() {
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		s.client.ShowMessage(lsp.MessageError, fmt.Sprintf("quest: failed to load quest data: %v", err))
+		return
+	}
+	if state.ExplainCount > 3 {
+		s.client.ShowMessage(lsp.MessageWarning, "quest: explain limit reached for this task")
+		return
+	}
+	tasks := FlattenTasks(plan)
+	if state.CurrentTaskIndex >= len(tasks) {
+		return
+	}
+	task := tasks[state.CurrentTaskIndex]
+	files := existingFiles(task.Artifacts)
+	if len(files) == 0 {
+		s.client.ShowMessage(lsp.MessageWarning, fmt.Sprintf("quest: no files found to analyze, expected %v", task.Artifacts))
+		return
+	}
+
+	attempt := state.ExplainCount + 1
+	hints, err := copilot_helper.GenerateHints(task.Title, task.Objective, files, attempt)
+	if err != nil {
+		s.client.ShowMessage(lsp.MessageError, fmt.Sprintf("quest: failed to generate hints: %v", err))
+		return
+	}
+	var given []HintReport
+	for _, hint := range hints {
+		given = append(given, HintReport{File: hint.File, Line: hint.Line, Comment: hint.Comment})
+	}
+	s.countExplain(state.CurrentTaskIndex, task, attempt, given)
+
+	diagnostics := map[string][]lsp.Diagnostic{}
+	for _, hint := range hints {
+		path := absPath(hint.File)
+		diagnostics[path] = append(diagnostics[path], lineDiagnostic(hint.Line, lsp.SeverityInformation, lspSourceHint, hint.Comment))
+	}
+	if len(hints) == 0 {
+		s.client.ShowMessage(lsp.MessageInfo, "quest: your code looks good, no hints needed")
+	}
+
+	s.mu.Lock()
+	s.hints = diagnostics
+	s.mu.Unlock()
+	s.publish()
+}
+
+// countExplain saves the explain count of a task and logs the hints given.
+// The state is read again since a check may have saved it while the hints
+// were generated.
+func (s *languageServer) countExplain(index int, task types.Task, count int, hints []HintReport) {
+	s.checkMu.Lock()
+	defer s.checkMu.Unlock()
+	unlock, err := LockQuest()
+	if err != nil {
+		s.client.LogMessage(lsp.MessageWarning, fmt.Sprintf("quest: failed to lock quest data: %v", err))
+		return
+	}
+	defer unlock()
+
+	state, err := LoadState()
+	if err != nil || state.CurrentTaskIndex != index {
+		return
+	}
+	state.ExplainCount = count
+	trackHint(state, task.ID)
+	if err := UploadState(state); err != nil {
+		s.client.LogMessage(lsp.MessageWarning, fmt.Sprintf("quest: failed to save state: %v", err))
+	}
+	logEvent(Event{Type: EventExplain, Task: index + 1, TaskID: task.ID, Hints: hints})
+}
+
+// annotate asks for an AI review of the last check like check --annotate
+func (s *languageServer) annotate() {
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		s.client.ShowMessage(lsp.MessageError, fmt.Sprintf("quest: failed to load quest data: %v", err))
+		return
+	}
+	tasks := FlattenTasks(plan)
+	if state.CurrentTaskIndex >= len(tasks) {
+		return
+	}
+	task := tasks[state.CurrentTaskIndex]
+	files := existingFiles(task.Artifacts)
+	if len(files) == 0 {
+		s.client.ShowMessage(lsp.MessageWarning, fmt.Sprintf("quest: no files found to review, expected %v", task.Artifacts))
+		return
+	}
+
+	annotations, err := copilot_helper.GenerateCheckAnnotations(task.Title, task.Validation.Rules, files)
+	if err != nil {
+		s.client.ShowMessage(lsp.MessageError, fmt.Sprintf("quest: failed to generate annotations: %v", err))
+		return
+	}
+
+	diagnostics := map[string][]lsp.Diagnostic{}
+	for _, ann := range annotations {
+		severity := lsp.SeverityInformation
+		switch ann.Type {
+		case "error":
+			severity = lsp.SeverityError
+		case "warning":
+			severity = lsp.SeverityWarning
+		}
+		path := absPath(ann.File)
+		diagnostics[path] = append(diagnostics[path], lineDiagnostic(ann.Line, severity, lspSourceReview, ann.Comment))
+	}
+
+	s.mu.Lock()
+	s.reviews = diagnostics
+	s.mu.Unlock()
+	s.publish()
+}
+
+// setTask forgets hints and reviews once the learner moved to another task
+func (s *languageServer) setTask(index int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index != s.taskIndex {
+		s.taskIndex = index
+		s.hints = nil
+		s.reviews = nil
+	}
+}
+
+func (s *languageServer) setChecks(checks map[string][]lsp.Diagnostic) {
+	s.mu.Lock()
+	s.checks = checks
+	s.mu.Unlock()
+	s.publish()
+}
+
+// publish sends the diagnostics of every file, clearing files that no
+// longer have any
+func (s *languageServer) publish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merged := map[string][]lsp.Diagnostic{}
+	for _, source := range []map[string][]lsp.Diagnostic{s.checks, s.hints, s.reviews} {
+		for path, diagnostics := range source {
+			merged[path] = append(merged[path], diagnostics...)
+		}
+	}
+	for path := range s.published {
+		if _, ok := merged[path]; !ok {
+			s.client.PublishDiagnostics(path, nil)
+			delete(s.published, path)
+		}
+	}
+	for path, diagnostics := range merged {
+		s.client.PublishDiagnostics(path, diagnostics)
+		s.published[path] = true
+	}
+}
+
+// checkDiagnostics places each failed rule on the file it found its evidence
+// in. Failures without an existing file go on the task's first file.
+func checkDiagnostics(task types.Task, results []RuleResult) map[string][]lsp.Diagnostic {
+	fallback := ""
+	if files := existingFiles(append(append([]string(nil), task.Artifacts...), task.Files...)); len(files) > 0 {
+		fallback = files[0]
+	}
+
+	diagnostics := map[string][]lsp.Diagnostic{}
+	for _, result := range results {
+		if result.Passed {
+			continue
+		}
+		file, line := result.File, result.Line
+		if file == "" || len(existingFiles([]string{file})) == 0 {
+			file, line = fallback, 0
+		}
+		if file == "" {
+			continue
+		}
+		message := result.Name
+		if reason := strings.TrimSpace(result.Reason); reason != "" {
+			message += ": " + reason
+		}
+		if result.Detail != "" {
+			message += "\n" + strings.TrimRight(result.Detail, "\n")
+		}
+		path := absPath(file)
+		diagnostics[path] = append(diagnostics[path], lineDiagnostic(line, lsp.SeverityError, lspSourceCheck, message))
+	}
+	return diagnostics
+}
+
+// lineDiagnostic covers a 1-based line, or the first line when it is unknown
+func lineDiagnostic(line int, severity lsp.DiagnosticSeverity, source, message string) lsp.Diagnostic {
+	if line > 0 {
+		line--
+	}
+	return lsp.Diagnostic{Range: lsp.LineRange(line), Severity: severity, Source: source, Message: message}
+}
+
+// existingFiles keeps the paths that exist as regular files
+func existingFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,134 @@
+package quest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/lsp"
+	"github.com/jovanpet/quest/internal/types"
+)
+
+type fakeLSPClient struct {
+	mu          sync.Mutex
+	diagnostics map[string][]lsp.Diagnostic
+	messages    []string
+}
+
+func (c *fakeLSPClient) PublishDiagnostics(path string, diagnostics []lsp.Diagnostic) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diagnostics[path] = diagnostics
+	return nil
+}
+
+func (c *fakeLSPClient) ShowMessage(kind lsp.MessageType, message string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, message)
+	return nil
+}
+
+func (c *fakeLSPClient) LogMessage(kind lsp.MessageType, message string) error {
+	return nil
+}
+
+func writeLSPQuest(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	plan := &types.Plan{
+		NumberOfTasks: 1,
+		Chapters: []types.Chapter{{Title: "Basics", Quests: []types.Quest{{Title: "Hello", Tasks: []types.Task{{
+			ID:        "hello",
+			Title:     "Say hello",
+			Objective: "Write a Hello function",
+			Steps:     []string{"Add func Hello"},
+			Artifacts: []string{"main.go"},
+			Validation: types.Validation{Rules: []types.Rule{
+				{Type: types.TypeFileContainsAny, Name: "hello func", Glob: "main.go", Any: []string{"func Hello"}},
+				{Type: types.TypeExists, Name: "readme", Path: "README.md"},
+			}},
+		}}}}}},
+	}
+	state := &types.State{Version: Version, CompletedTaskIDs: []string{}, QuestStarted: true}
+	os.MkdirAll(QuestDir, 0755)
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("main.go", []byte("package main\n\nfunc main() {}\n"), 0644)
+}
+
+func TestLanguageServerCheck(t *testing.T) {
+	writeLSPQuest(t)
+	client := &fakeLSPClient{diagnostics: map[string][]lsp.Diagnostic{}}
+	ls := newLanguageServer(testCommand(t, "lsp"), client)
+
+	ls.check(context.Background())
+	main, _ := filepath.Abs("main.go")
+	diagnostics := client.diagnostics[main]
+	if len(diagnostics) != 2 {
+		t.Fatalf("Expected both failures on main.go, got %+v", client.diagnostics)
+	}
+	for _, d := range diagnostics {
+		if d.Source != lspSourceCheck || d.Severity != lsp.SeverityError {
+			t.Errorf("Expected an error from quest, got %+v", d)
+		}
+	}
+	if !strings.Contains(diagnostics[1].Message, "README.md") {
+		t.Errorf("Expected the missing README to be reported, got %s", diagnostics[1].Message)
+	}
+
+	os.WriteFile("main.go", []byte("package main\n\nfunc Hello() string { return \"hi\" }\n"), 0644)
+	os.WriteFile("README.md", []byte("# hello\n"), 0644)
+	ls.check(context.Background())
+	if len(client.diagnostics[main]) != 0 {
+		t.Errorf("Expected the diagnostics to be cleared once the task passes, got %+v", client.diagnostics[main])
+	}
+	state, err := LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(state.CompletedTaskIDs, "hello") || state.LastCheck.Status != types.CheckPass {
+		t.Errorf("Expected the passing check to be recorded, got %+v", state)
+	}
+}
+
+func TestLanguageServerHoverAndActions(t *testing.T) {
+	writeLSPQuest(t)
+	client := &fakeLSPClient{diagnostics: map[string][]lsp.Diagnostic{}}
+	ls := newLanguageServer(testCommand(t, "lsp"), client)
+
+	hover, err := ls.Hover("main.go", lsp.Position{})
+	if err != nil || hover == nil {
+		t.Fatalf("Expected hover text, got %v", err)
+	}
+	if !strings.Contains(hover.Contents.Value, "Write a Hello function") || !strings.Contains(hover.Contents.Value, "1. Add func Hello") {
+		t.Errorf("Expected the objective and steps, got %q", hover.Contents.Value)
+	}
+
+	hint := lineDiagnostic(3, lsp.SeverityInformation, lspSourceHint, "Return a greeting")
+	actions, _ := ls.CodeActions("main.go", lsp.CodeActionParams{Context: lsp.CodeActionContext{Diagnostics: []lsp.Diagnostic{hint}}})
+	if len(actions) == 0 || actions[0].Command.Command != lspCommandShowHint {
+		t.Fatalf("Expected an action to view the hint, got %+v", actions)
+	}
+	if hint.Range.Start.Line != 2 {
+		t.Errorf("Expected line 3 to be 0-based line 2, got %d", hint.Range.Start.Line)
+	}
+
+	if err := ls.ExecuteCommand(lspCommandShowHint, []json.RawMessage{json.RawMessage(`"Return a greeting"`)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.messages) != 1 || client.messages[0] != "Return a greeting" {
+		t.Errorf("Expected the hint to be shown, got %v", client.messages)
+	}
+}
@@ -0,0 +1,123 @@
+package quest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jovanpet/quest/internal/format"
+)
+
+// ErrNewerSchema is returned when a state or plan file was written by a
+// newer quest than this one, which would drop the fields it doesn't know
+var ErrNewerSchema = errors.New("written by a newer version of quest")
+
+// migration upgrades a decoded state or plan file from schema version From
+// to From+1. Versions in which a file didn't change need no migration.
+type migration struct {
+	From        int
+	Description string
+	Apply       func(doc map[string]interface{}) error
+}
+
+// stateMigrations and planMigrations are applied in order to files older
+// than Version. Add one here, with a test, for every change to types that
+// old files can't be decoded into as they are, and bump Version with it. A
+// new field whose zero value suits files without it needs neither.
+var stateMigrations = []migration{
+	{
+		From:        0,
+		Description: "add the version and start with an empty completed list",
+		Apply: func(doc map[string]interface{}) error {
+			if doc["completedTaskIds"] == nil {
+				doc["completedTaskIds"] = []interface{}{}
+			}
+			return nil
+		},
+	},
+}
+
+// The plan kept its layout so far, older plans only need the version bumped
+var planMigrations []migration
+
+// loadVersioned reads a state or plan file and brings it to the current
+// schema version in memory. Nothing is written, so reading an archived quest
+// or a quest another command is saving is safe: the upgraded file replaces
+// the old one when a command holding the lock saves it, see
+// backupOlderSchema.
+func loadVersioned(path string, migrations []migration) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	if header.Version == Version {
+		return data, nil
+	}
+	if header.Version > Version {
+		return nil, fmt.Errorf("%s has schema version %d but this quest supports up to %d: %w", path, header.Version, Version, ErrNewerSchema)
+	}
+
+	migrated, err := migrate(data, header.Version, migrations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upgrade %s from schema version %d: %w", path, header.Version, err)
+	}
+	return migrated, nil
+}
+
+// backupOlderSchema keeps a file written with an older schema version next
+// to it as <name>.v<version>.bak, right before it is first saved in the
+// current one
+func backupOlderSchema(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var header struct {
+		Version int `json:"version"`
+	}
+	if json.Unmarshal(data, &header) != nil || header.Version >= Version {
+		return nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, header.Version)
+	if _, err := os.Stat(backup); err == nil {
+		return nil
+	}
+	if err := writeFileAtomic(backup, data, 0644); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	format.Info(fmt.Sprintf("Upgraded %s to schema version %d, the original is in %s", path, Version, backup))
+	return nil
+}
+
+// migrate applies every migration from version onwards to data. Numbers are
+// kept as written so that values such as test case arguments survive.
+func migrate(data []byte, version int, migrations []migration) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	for _, m := range migrations {
+		if m.From < version {
+			continue
+		}
+		if err := m.Apply(doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Description, err)
+		}
+	}
+	doc["version"] = Version
+
+	return json.MarshalIndent(doc, "", "  ")
+}
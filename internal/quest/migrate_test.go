@@ -0,0 +1,95 @@
+package quest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMigrationsOrdered(t *testing.T) {
+	for name, migrations := range map[string][]migration{"state": stateMigrations, "plan": planMigrations} {
+		last := -1
+		for _, m := range migrations {
+			if m.From <= last || m.From >= Version {
+				t.Errorf("%s migration from %d is out of order or past version %d", name, m.From, Version)
+			}
+			last = m.From
+		}
+	}
+}
+
+func TestLoadStateMigrates(t *testing.T) {
+	chdirTemp(t)
+	original := `{"currentTaskIndex": 2, "completedTaskIds": null, "questStarted": true}`
+	os.WriteFile(StateFilePath, []byte(original), 0644)
+
+	state, err := LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != Version || state.CurrentTaskIndex != 2 || state.CompletedTaskIDs == nil {
+		t.Errorf("Expected an upgraded state, got %+v", state)
+	}
+	// Reading alone leaves the file as it was
+	entries, _ := os.ReadDir(QuestDir)
+	if data, _ := os.ReadFile(StateFilePath); string(data) != original || len(entries) != 1 {
+		t.Errorf("Expected loading not to write anything, got %s and %v", data, entries)
+	}
+
+	if err := UploadState(state); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := os.ReadFile(StateFilePath + ".v0.bak")
+	if err != nil || string(backup) != original {
+		t.Errorf("Expected the original to be backed up, got %q (%v)", backup, err)
+	}
+	data, _ := os.ReadFile(StateFilePath)
+	if !strings.Contains(string(data), fmt.Sprintf(`"version": %d`, Version)) {
+		t.Errorf("Expected the upgraded state to be saved, got %s", data)
+	}
+}
+
+func TestLoadPlanKeepsNumbers(t *testing.T) {
+	chdirTemp(t)
+	os.WriteFile(PlanFilePath, []byte(`{"chapters": [{"quests": [{"tasks": [{"validation": {"rules": [
+		{"type": "function_cases", "cases": [{"args": [9007199254740993], "expected": 1.50}]}
+	]}}]}]}]}`), 0644)
+
+	plan, err := LoadPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := plan.Chapters[0].Quests[0].Tasks[0].Validation.Rules[0].Cases[0]
+	if plan.Version != Version || string(c.Args[0]) != "9007199254740993" || string(c.Expected) != "1.50" {
+		t.Errorf("Expected the plan to upgrade with its numbers intact, got version %d, %s, %s", plan.Version, c.Args[0], c.Expected)
+	}
+}
+
+func TestLoadStateNewerSchema(t *testing.T) {
+	chdirTemp(t)
+	os.WriteFile(StateFilePath, []byte(fmt.Sprintf(`{"version": %d}`, Version+1)), 0644)
+
+	if _, err := LoadState(); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Expected a newer state to be refused, got %v", err)
+	}
+	if _, err := os.Stat(StateFilePath + fmt.Sprintf(".v%d.bak", Version+1)); !os.IsNotExist(err) {
+		t.Error("Expected a refused state to be left alone")
+	}
+}
+
+func TestLoadStateCurrent(t *testing.T) {
+	chdirTemp(t)
+	current := fmt.Sprintf(`{"version": %d, "completedTaskIds": []}`, Version)
+	os.WriteFile(StateFilePath, []byte(current), 0644)
+
+	if _, err := LoadState(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(QuestDir)
+	data, _ := os.ReadFile(StateFilePath)
+	if len(entries) != 1 || string(data) != current {
+		t.Errorf("Expected a current state to be read as is, got %s and %v", data, entries)
+	}
+}
@@ -0,0 +1,368 @@
+package quest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jovanpet/quest/internal/configfile"
+	"github.com/jovanpet/quest/internal/schema"
+	"github.com/jovanpet/quest/internal/types"
+)
+
+// operationOrder creates resources before reading them and deletes last
+var operationOrder = []string{"post", "put", "patch", "get", "head", "options", "delete"}
+
+// OperationResult is the outcome of exercising one documented operation
+type OperationResult struct {
+	Method  string
+	Path    string
+	Status  int
+	Passed  bool
+	Message string
+}
+
+func (o OperationResult) Name() string {
+	return fmt.Sprintf("%s %s", strings.ToUpper(o.Method), o.Path)
+}
+
+// runOpenAPIContract starts the learner's server and calls every operation
+// in the rule's OpenAPI document with a generated example request.
+func runOpenAPIContract(ctx context.Context, rule types.Rule, workspace *workspaceCopy) ([]OperationResult, error) {
+	doc, err := loadOpenAPIDocument(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("OpenAPI document has no paths")
+	}
+
+	baseURL := rule.BaseURL
+	if baseURL == "" {
+		baseURL = documentBaseURL(doc)
+	}
+
+	stop, err := startServer(ctx, rule.Command, baseURL, workspace)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	pathNames := make([]string, 0, len(paths))
+	for p := range paths {
+		pathNames = append(pathNames, p)
+	}
+	sort.Strings(pathNames)
+
+	var results []OperationResult
+	for _, method := range operationOrder {
+		for _, p := range pathNames {
+			item, _ := paths[p].(map[string]interface{})
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			results = append(results, exerciseOperation(ctx, client, doc, baseURL, p, method, item, op))
+		}
+	}
+	return results, nil
+}
+
+func loadOpenAPIDocument(rule types.Rule) (map[string]interface{}, error) {
+	var raw interface{}
+	switch {
+	case len(rule.Document) > 0:
+		if err := json.Unmarshal(rule.Document, &raw); err != nil {
+			return nil, fmt.Errorf("invalid inline OpenAPI document: %w", err)
+		}
+	case rule.Spec != "":
+		value, err := configfile.ParseFile(rule.Spec, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OpenAPI document %s: %w", rule.Spec, err)
+		}
+		raw = value
+	default:
+		return nil, fmt.Errorf("openapi rule needs a 'spec' path or an inline 'document'")
+	}
+
+	doc, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("OpenAPI document must be an object")
+	}
+	return doc, nil
+}
+
+// documentBaseURL uses the first absolute server URL in the document
+func documentBaseURL(doc map[string]interface{}) string {
+	servers, _ := doc["servers"].([]interface{})
+	for _, s := range servers {
+		server, _ := s.(map[string]interface{})
+		if u, ok := server["url"].(string); ok && strings.HasPrefix(u, "http") {
+			return strings.TrimRight(u, "/")
+		}
+	}
+	return DefaultBaseURL
+}
+
+func exerciseOperation(ctx context.Context, client *http.Client, doc map[string]interface{}, baseURL, path, method string, item, op map[string]interface{}) OperationResult {
+	result := OperationResult{Method: method, Path: path}
+
+	target, err := buildOperationURL(doc, baseURL, path, item, op)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	var body io.Reader
+	if example, ok := requestBodyExample(doc, op); ok {
+		data, _ := json.Marshal(example)
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), target, body)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Message = fmt.Sprintf("request failed: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(resp.Body)
+	result.Status = resp.StatusCode
+
+	responses, _ := op["responses"].(map[string]interface{})
+	response, ok := responseFor(responses, resp.StatusCode)
+	if !ok {
+		result.Message = fmt.Sprintf("returned %d, documented: %s", resp.StatusCode, documentedStatuses(responses))
+		return result
+	}
+
+	responseSchema, ok := jsonContentSchema(response)
+	if !ok {
+		result.Passed = true
+		result.Message = fmt.Sprintf("- %d", resp.StatusCode)
+		return result
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(respBody, &decoded); err != nil {
+		result.Message = fmt.Sprintf("returned %d with a body that is not JSON", resp.StatusCode)
+		return result
+	}
+	if problems := schema.ValidateIn(decoded, responseSchema, doc); len(problems) > 0 {
+		if len(problems) > maxReportedProblems {
+			problems = append(problems[:maxReportedProblems], fmt.Sprintf("and %d more", len(problems)-maxReportedProblems))
+		}
+		result.Message = fmt.Sprintf("returned %d, body does not match schema: %s", resp.StatusCode, strings.Join(problems, "; "))
+		return result
+	}
+
+	result.Passed = true
+	result.Message = fmt.Sprintf("- %d matches schema", resp.StatusCode)
+	return result
+}
+
+func buildOperationURL(doc map[string]interface{}, baseURL, path string, item, op map[string]interface{}) (string, error) {
+	var params []interface{}
+	params = append(params, asList(item["parameters"])...)
+	params = append(params, asList(op["parameters"])...)
+	query := url.Values{}
+
+	for _, p := range params {
+		param, ok := resolveObject(doc, p)
+		if !ok {
+			continue
+		}
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		required, _ := param["required"].(bool)
+
+		value := param["example"]
+		if value == nil {
+			value = exampleFromSchema(doc, param["schema"], 0)
+		}
+
+		switch {
+		case in == "path":
+			path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(fmt.Sprint(value)))
+		case in == "query" && required:
+			query.Set(name, fmt.Sprint(value))
+		}
+	}
+
+	if strings.Contains(path, "{") {
+		return "", fmt.Errorf("path parameters in %s are not documented", path)
+	}
+
+	target := strings.TrimRight(baseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	return target, nil
+}
+
+func requestBodyExample(doc map[string]interface{}, op map[string]interface{}) (interface{}, bool) {
+	requestBody, ok := resolveObject(doc, op["requestBody"])
+	if !ok {
+		return nil, false
+	}
+	media, ok := jsonMediaType(requestBody)
+	if !ok {
+		return nil, false
+	}
+	if example, ok := media["example"]; ok {
+		return example, true
+	}
+	return exampleFromSchema(doc, media["schema"], 0), true
+}
+
+// exampleFromSchema builds a value that satisfies the common parts of s
+func exampleFromSchema(doc map[string]interface{}, s interface{}, depth int) interface{} {
+	obj, ok := resolveObject(doc, s)
+	if !ok || depth > 8 {
+		return "1"
+	}
+	for _, key := range []string{"example", "default", "const"} {
+		if v, ok := obj[key]; ok {
+			return v
+		}
+	}
+	if enum, ok := obj["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if all, ok := obj["allOf"].([]interface{}); ok && len(all) > 0 {
+		merged := map[string]interface{}{}
+		for _, sub := range all {
+			if part, ok := exampleFromSchema(doc, sub, depth+1).(map[string]interface{}); ok {
+				for k, v := range part {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options, ok := obj[key].([]interface{}); ok && len(options) > 0 {
+			return exampleFromSchema(doc, options[0], depth+1)
+		}
+	}
+
+	t, _ := obj["type"].(string)
+	switch t {
+	case "object", "":
+		if properties, ok := obj["properties"].(map[string]interface{}); ok || t == "object" {
+			result := map[string]interface{}{}
+			for name, propSchema := range properties {
+				result[name] = exampleFromSchema(doc, propSchema, depth+1)
+			}
+			return result
+		}
+		return "1"
+	case "array":
+		return []interface{}{exampleFromSchema(doc, obj["items"], depth+1)}
+	case "integer":
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	}
+
+	switch obj["format"] {
+	case "date-time":
+		return time.Now().UTC().Format(time.RFC3339)
+	case "date":
+		return time.Now().UTC().Format("2006-01-02")
+	case "email":
+		return "learner@example.com"
+	case "uuid":
+		return "00000000-0000-4000-8000-000000000001"
+	}
+	return "example"
+}
+
+// responseFor finds the documented response for a status code, trying the
+// exact code, then the NXX range, then "default"
+func responseFor(responses map[string]interface{}, status int) (map[string]interface{}, bool) {
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if r, ok := responses[key].(map[string]interface{}); ok {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+func documentedStatuses(responses map[string]interface{}) string {
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ", ")
+}
+
+func jsonContentSchema(response map[string]interface{}) (interface{}, bool) {
+	media, ok := jsonMediaType(response)
+	if !ok {
+		return nil, false
+	}
+	s, ok := media["schema"]
+	return s, ok
+}
+
+func jsonMediaType(obj map[string]interface{}) (map[string]interface{}, bool) {
+	content, _ := obj["content"].(map[string]interface{})
+	for mediaType, media := range content {
+		if strings.HasPrefix(mediaType, "application/json") {
+			m, ok := media.(map[string]interface{})
+			return m, ok
+		}
+	}
+	return nil, false
+}
+
+// resolveObject returns v as an object, following a local $ref
+func resolveObject(doc map[string]interface{}, v interface{}) (map[string]interface{}, bool) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	if ref, ok := obj["$ref"].(string); ok {
+		resolved, err := schema.Resolve(doc, ref)
+		if err != nil {
+			return nil, false
+		}
+		obj, ok = resolved.(map[string]interface{})
+		return obj, ok
+	}
+	return obj, true
+}
+
+func asList(v interface{}) []interface{} {
+	list, _ := v.([]interface{})
+	return list
+}
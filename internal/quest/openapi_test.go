@@ -0,0 +1,135 @@
+package quest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+const contractDocument = `{
+	"openapi": "3.0.3",
+	"paths": {
+		"/todos": {
+			"get": {"responses": {"200": {"content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Todo"}}}}}}},
+			"post": {
+				"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Todo"}}}},
+				"responses": {"201": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Todo"}}}}}
+			}
+		},
+		"/todos/{id}": {
+			"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+			"delete": {"responses": {"204": {"description": "deleted"}}}
+		}
+	},
+	"components": {"schemas": {"Todo": {
+		"type": "object",
+		"required": ["id", "title"],
+		"properties": {"id": {"type": "integer"}, "title": {"type": "string"}}
+	}}}
+}`
+
+const contractServer = `package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func main() {
+	http.HandleFunc("/todos", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "title": "x"})
+			return
+		}
+		// Bug on purpose: title is missing
+		json.NewEncoder(w).Encode([]map[string]interface{}{{"id": 1}})
+	})
+	http.HandleFunc("/todos/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	http.ListenAndServe("%s", nil)
+}
+`
+
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestRunOpenAPIContract(t *testing.T) {
+	dir := t.TempDir()
+	address := freeAddress(t)
+
+	files := map[string]string{
+		"go.mod":  "module example.com/todo\n\ngo 1.18\n",
+		"main.go": fmt.Sprintf(contractServer, address),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	rule := types.Rule{
+		Type:     types.TypeOpenAPI,
+		Document: json.RawMessage(contractDocument),
+		BaseURL:  "http://" + address,
+	}
+
+	results, err := runOpenAPIContract(context.Background(), rule, nil)
+	if err != nil {
+		t.Fatalf("runOpenAPIContract() error = %v", err)
+	}
+
+	expected := map[string]bool{
+		"POST /todos":        true,
+		"GET /todos":         false,
+		"DELETE /todos/{id}": false,
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d operations, got %d: %+v", len(expected), len(results), results)
+	}
+	for _, result := range results {
+		if result.Passed != expected[result.Name()] {
+			t.Errorf("%s: expected passed=%v, got %v (%s)", result.Name(), expected[result.Name()], result.Passed, result.Message)
+		}
+	}
+
+	if conn, err := net.Dial("tcp", address); err == nil {
+		conn.Close()
+		t.Error("Expected the server to be stopped after the contract run")
+	}
+}
+
+func TestExampleFromSchema(t *testing.T) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(contractDocument), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	example, ok := exampleFromSchema(doc, map[string]interface{}{"$ref": "#/components/schemas/Todo"}, 0).(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected an object example")
+	}
+	if example["id"] != 1 || example["title"] != "example" {
+		t.Errorf("Unexpected example: %v", example)
+	}
+}
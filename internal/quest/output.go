@@ -0,0 +1,244 @@
+package quest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// Output modes for the global --output flag
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// ReportVersion is the layout version of the JSON documents printed with
+// --output json. Adding a field keeps it, renaming, removing or changing the
+// meaning of one bumps it.
+const ReportVersion = 1
+
+// JSONOutputAnnotation marks a command that can report with --output json
+const JSONOutputAnnotation = "quest/json-output"
+
+// ProtocolOutputAnnotation marks a command whose stdout carries a protocol,
+// like the language server, so messages printed before it starts go to stderr
+const ProtocolOutputAnnotation = "quest/protocol-output"
+
+// reportOutput receives the JSON documents, format output is discarded
+var reportOutput io.Writer = os.Stdout
+
+// ConfigureOutput applies the --output flag before a command runs. In JSON
+// mode nothing is printed until the command writes its report.
+func ConfigureOutput(cmd *cobra.Command) error {
+	if cmd.Annotations[ProtocolOutputAnnotation] != "" {
+		format.SetOutput(os.Stderr)
+	}
+	mode, _ := cmd.Flags().GetString("output")
+	switch mode {
+	case "", OutputText:
+		return nil
+	case OutputJSON:
+		if cmd.Annotations[JSONOutputAnnotation] == "" {
+			return fmt.Errorf("'quest %s' does not support --output json", cmd.Name())
+		}
+		format.SetOutput(io.Discard)
+		return nil
+	}
+	return fmt.Errorf("unknown output mode '%s', use '%s' or '%s'", mode, OutputText, OutputJSON)
+}
+
+// jsonOutput reports whether the command was asked for --output json
+func jsonOutput(cmd *cobra.Command) bool {
+	mode, _ := cmd.Flags().GetString("output")
+	return mode == OutputJSON
+}
+
+// Report is the single JSON document a command prints with --output json.
+// Fields a command has nothing to say about are left out.
+type Report struct {
+	Version  int    `json:"version"`
+	Command  string `json:"command"`
+	Status   string `json:"status"` // "pass", "updated", "warn", "fail" or "error"
+	ExitCode int    `json:"exitCode"`
+	Error    string `json:"error,omitempty"`
+
+	// The task the command is about, or every task checked by check --all,
+	// --task and --chapter
+	Task  *TaskReport  `json:"task,omitempty"`
+	Tasks []TaskReport `json:"tasks,omitempty"`
+
+	Progress *ProgressReport `json:"progress,omitempty"`
+	Hints    []HintReport    `json:"hints,omitempty"`
+
+	// Results of quest health
+	Health []RuleResult `json:"health,omitempty"`
+
+	// Entries of the activity log shown by quest log
+	Events []Event `json:"events,omitempty"`
+
+	// Time and effort per task from quest stats
+	Stats *StatsReport `json:"stats,omitempty"`
+
+	// The quests in the workspace from quest list-quests
+	Quests []QuestInfo `json:"quests,omitempty"`
+
+	// Finished quests from quest history
+	Archives []ArchiveSummary `json:"archives,omitempty"`
+
+	enabled bool
+}
+
+// TaskReport describes one task and, when it was checked, its results
+type TaskReport struct {
+	Number    int      `json:"number"` // 1-based position in the quest
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Objective string   `json:"objective,omitempty"`
+	Steps     []string `json:"steps,omitempty"`
+	Artifacts []string `json:"artifacts,omitempty"`
+	Status    string   `json:"status,omitempty"` // "completed", "regressed", "current" or "pending"
+
+	Check *TaskCheck `json:"check,omitempty"`
+}
+
+// TaskCheck is the outcome of checking a task
+type TaskCheck struct {
+	Status  types.CheckStatus `json:"status"`
+	Passed  int               `json:"passed"`
+	Failed  int               `json:"failed"`
+	Updated int               `json:"updated,omitempty"` // goldens rewritten by --update-goldens
+	Results []RuleResult      `json:"results"`
+}
+
+// ProgressReport is how far the learner got through the quest
+type ProgressReport struct {
+	Journey     string            `json:"journey"`
+	CurrentTask int               `json:"currentTask"` // 1-based
+	Completed   int               `json:"completed"`
+	Total       int               `json:"total"`
+	Regressed   []string          `json:"regressed,omitempty"`
+	Finished    bool              `json:"finished"`
+	Chapters    []ChapterProgress `json:"chapters,omitempty"`
+}
+
+// ChapterProgress lists the tasks of a chapter with their status
+type ChapterProgress struct {
+	Number int          `json:"number"`
+	Title  string       `json:"title"`
+	Tasks  []TaskReport `json:"tasks"`
+}
+
+// HintReport is an AI hint or check annotation added to the learner's code
+type HintReport struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Type    string `json:"type,omitempty"` // for check annotations: "success", "warning", "error"
+	Comment string `json:"comment"`
+}
+
+// newReport starts the report of a command. It is filled in as the command
+// runs and only written when --output json was given.
+func newReport(cmd *cobra.Command, command string) *Report {
+	return &Report{Version: ReportVersion, Command: command, enabled: jsonOutput(cmd)}
+}
+
+// finish writes the report with the outcome of err and returns err
+// unchanged, so a command can end with it from a deferred call
+func (r *Report) finish(err error) error {
+	if !r.enabled {
+		return err
+	}
+	r.ExitCode = ExitCode(err)
+	switch r.ExitCode {
+	case ExitPass:
+		// check --update-goldens sets "updated" itself
+		if r.Status == "" {
+			r.Status = "pass"
+		}
+	case ExitFail:
+		r.Status = "fail"
+	case ExitWarn:
+		r.Status = "warn"
+	default:
+		r.Status = "error"
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	encoder := json.NewEncoder(reportOutput)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(r); encodeErr != nil && err == nil {
+		return exitWith(ExitInternal, encodeErr)
+	}
+	return err
+}
+
+// taskReport describes the task at index of the flattened task list
+func taskReport(index int, task types.Task, state *types.State) TaskReport {
+	status := "pending"
+	switch {
+	case contains(state.RegressedTaskIDs, task.ID):
+		status = "regressed"
+	case contains(state.CompletedTaskIDs, task.ID):
+		status = "completed"
+	case state.QuestStarted && index == state.CurrentTaskIndex:
+		status = "current"
+	}
+	return TaskReport{
+		Number:    index + 1,
+		ID:        task.ID,
+		Title:     task.Title,
+		Objective: task.Objective,
+		Steps:     task.Steps,
+		Artifacts: task.Artifacts,
+		Status:    status,
+	}
+}
+
+// taskCheck records the results of checking a task
+func taskCheck(passedCount, failedCount int, results []RuleResult) *TaskCheck {
+	status := types.CheckPass
+	if failedCount > 0 {
+		status = types.CheckFail
+	}
+	if results == nil {
+		results = []RuleResult{}
+	}
+	return &TaskCheck{Status: status, Passed: passedCount, Failed: failedCount, Results: results}
+}
+
+// progressReport summarizes the progress stored in state
+func progressReport(plan *types.Plan, state *types.State) *ProgressReport {
+	return &ProgressReport{
+		Journey:     plan.Journey.Name,
+		CurrentTask: state.CurrentTaskIndex + 1,
+		Completed:   len(state.CompletedTaskIDs),
+		Total:       plan.NumberOfTasks,
+		Regressed:   state.RegressedTaskIDs,
+		Finished:    ifCompletedPlan(*plan, *state),
+	}
+}
+
+// summaryReport is the progress shown by quest summary, with the status of
+// every task by chapter
+func summaryReport(plan *types.Plan, state *types.State) *ProgressReport {
+	progress := progressReport(plan, state)
+	index := 0
+	for chIdx, ch := range plan.Chapters {
+		chapter := ChapterProgress{Number: chIdx + 1, Title: ch.Title, Tasks: []TaskReport{}}
+		for _, q := range ch.Quests {
+			for _, task := range q.Tasks {
+				chapter.Tasks = append(chapter.Tasks, taskReport(index, task, state))
+				index++
+			}
+		}
+		progress.Chapters = append(progress.Chapters, chapter)
+	}
+	return progress
+}
@@ -0,0 +1,150 @@
+package quest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/spf13/cobra"
+)
+
+// captureReport runs a command in JSON mode and decodes the report it writes
+func captureReport(t *testing.T, cmd *cobra.Command, run func(*cobra.Command, []string) error, args ...string) (Report, error) {
+	t.Helper()
+	if cmd.Flags().Lookup("output") == nil {
+		cmd.Flags().String("output", OutputText, "")
+	}
+	cmd.Flags().Set("output", OutputJSON)
+
+	var buf bytes.Buffer
+	reportOutput = &buf
+	format.SetOutput(io.Discard)
+	t.Cleanup(func() {
+		reportOutput = os.Stdout
+		format.SetOutput(os.Stdout)
+	})
+
+	err := run(cmd, args)
+	var report Report
+	if decodeErr := json.Unmarshal(buf.Bytes(), &report); decodeErr != nil {
+		t.Fatalf("Expected a single JSON document, got %q: %v", buf.String(), decodeErr)
+	}
+	return report, err
+}
+
+func TestConfigureOutput(t *testing.T) {
+	// summary supports --output json, begin doesn't
+	newCmd := func(mode string, supported bool) *cobra.Command {
+		if supported {
+			return testCommand(t, "summary", "--output", mode)
+		}
+		return testCommand(t, "begin", "--output", mode)
+	}
+	t.Cleanup(func() { format.SetOutput(os.Stdout) })
+
+	if err := ConfigureOutput(newCmd(OutputText, false)); err != nil {
+		t.Errorf("Expected text output to work everywhere, got %v", err)
+	}
+	if err := ConfigureOutput(newCmd(OutputJSON, true)); err != nil {
+		t.Errorf("Expected JSON output for an annotated command, got %v", err)
+	}
+	if err := ConfigureOutput(newCmd(OutputJSON, false)); err == nil {
+		t.Error("Expected an error for JSON output of an unsupported command")
+	}
+	if err := ConfigureOutput(newCmd("yaml", true)); err == nil {
+		t.Error("Expected an error for an unknown output mode")
+	}
+}
+
+func TestCheckJSONReport(t *testing.T) {
+	writeRegressionQuest(t)
+
+	report, err := captureReport(t, testCommand(t, "check"), RunCheck)
+	if ExitCode(err) != ExitFail {
+		t.Fatalf("Expected exit code %d for a failing task, got %d", ExitFail, ExitCode(err))
+	}
+	if report.Version != ReportVersion || report.Command != "check" {
+		t.Errorf("Unexpected report header: version %d, command %q", report.Version, report.Command)
+	}
+	if report.Status != "fail" || report.ExitCode != ExitFail {
+		t.Errorf("Expected status fail with exit code %d, got %q and %d", ExitFail, report.Status, report.ExitCode)
+	}
+	if report.Task == nil || report.Task.ID != "handlers" || report.Task.Check == nil {
+		t.Fatalf("Expected the checked task in the report, got %+v", report.Task)
+	}
+	check := report.Task.Check
+	if check.Failed != 1 || len(check.Results) != 1 || check.Results[0].Passed {
+		t.Errorf("Expected one failing rule result, got %+v", check)
+	}
+	if report.Progress == nil || report.Progress.Completed != 2 || report.Progress.Total != 3 {
+		t.Errorf("Expected progress 2/3, got %+v", report.Progress)
+	}
+
+	os.WriteFile("handlers.go", []byte("package main\n"), 0644)
+	report, err = captureReport(t, testCommand(t, "check"), RunCheck)
+	if err != nil {
+		t.Fatalf("Expected the check to pass, got %v", err)
+	}
+	if report.Status != "pass" || report.Task.Status != "completed" || report.Progress.Completed != 3 {
+		t.Errorf("Expected a passing, completed task, got %q, %q and %d completed", report.Status, report.Task.Status, report.Progress.Completed)
+	}
+}
+
+func TestCheckAllJSONReport(t *testing.T) {
+	writeRegressionQuest(t)
+	os.Remove("go.mod")
+
+	report, err := captureReport(t, testCommand(t, "check", "--all"), RunCheck)
+	if ExitCode(err) != ExitFail {
+		t.Fatalf("Expected exit code %d, got %d", ExitFail, ExitCode(err))
+	}
+	if len(report.Tasks) != 3 {
+		t.Fatalf("Expected every completed task plus the current one, got %d", len(report.Tasks))
+	}
+	if report.Tasks[0].Status != "regressed" || report.Tasks[0].Check.Status != "fail" {
+		t.Errorf("Expected go-mod to be reported as regressed, got %+v", report.Tasks[0])
+	}
+}
+
+func TestSummaryJSONReport(t *testing.T) {
+	writeRegressionQuest(t)
+
+	report, err := captureReport(t, testCommand(t, "summary"), RunSummary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress := report.Progress
+	if progress == nil || len(progress.Chapters) != 2 {
+		t.Fatalf("Expected two chapters, got %+v", progress)
+	}
+	var statuses []string
+	for _, ch := range progress.Chapters {
+		for _, task := range ch.Tasks {
+			statuses = append(statuses, task.Status)
+		}
+	}
+	if got := strings.Join(statuses, ","); got != "completed,completed,current" {
+		t.Errorf("Unexpected task statuses %s", got)
+	}
+}
+
+func TestJSONReportWithoutQuest(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	report, err := captureReport(t, testCommand(t, "summary"), RunSummary)
+	if ExitCode(err) != ExitNotInitialized {
+		t.Fatalf("Expected exit code %d, got %d", ExitNotInitialized, ExitCode(err))
+	}
+	if report.Status != "error" || report.Error == "" {
+		t.Errorf("Expected an error report, got status %q and error %q", report.Status, report.Error)
+	}
+}
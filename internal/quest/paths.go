@@ -1,9 +1,62 @@
 package quest
 
+import "path/filepath"
+
 const QuestFolderName = ".quest"
 const PlanFileName = "plan.json"
 const StateFileName = "state.json"
 const Version = 1
 
-const StateFilePath = QuestFolderName + "/" + StateFileName
-const PlanFilePath = QuestFolderName + "/" + PlanFileName
+// DefaultQuestName is used when no quest was named, and is where a .quest
+// folder from before named quests is moved to
+const DefaultQuestName = "default"
+
+// questRoot is the directory holding .quest, set by EnterQuestRoot once it
+// found one. QuestFolderPath and the paths below are absolute from then on,
+// so they still resolve after a command moved into its quest's workspace.
+var questRoot string
+
+// QuestFolderPath is the .quest folder of the workspace
+var QuestFolderPath = QuestFolderName
+
+// CurrentQuestFilePath holds the name of the quest commands use without --quest
+var CurrentQuestFilePath = filepath.Join(QuestFolderName, "current")
+
+// Paths of the selected quest in .quest/<name>, set by UseQuest
+var (
+	QuestName           string
+	QuestDir            string
+	StateFilePath       string
+	PlanFilePath        string
+	GoldensFolderPath   string
+	CacheFilePath       string
+	LockFilePath        string
+	EventsFilePath      string
+	SnapshotsFolderPath string
+)
+
+func init() {
+	UseQuest(DefaultQuestName)
+}
+
+// setQuestRoot makes root the directory holding .quest, or the working
+// directory when root is empty
+func setQuestRoot(root string) {
+	questRoot = root
+	QuestFolderPath = filepath.Join(root, QuestFolderName)
+	CurrentQuestFilePath = filepath.Join(QuestFolderPath, "current")
+	UseQuest(QuestName)
+}
+
+// UseQuest points every quest path at the quest called name
+func UseQuest(name string) {
+	QuestName = name
+	QuestDir = filepath.Join(QuestFolderPath, name)
+	StateFilePath = filepath.Join(QuestDir, StateFileName)
+	PlanFilePath = filepath.Join(QuestDir, PlanFileName)
+	GoldensFolderPath = filepath.Join(QuestDir, "goldens")
+	CacheFilePath = filepath.Join(QuestDir, "cache.json")
+	LockFilePath = filepath.Join(QuestDir, "lock")
+	EventsFilePath = filepath.Join(QuestDir, "events.jsonl")
+	SnapshotsFolderPath = filepath.Join(QuestDir, "snapshots")
+}
@@ -0,0 +1,148 @@
+package quest
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// strayOutputGrace bounds how long output is still read once the command and
+// its strays are gone, in case something that couldn't be found holds a pipe
+const strayOutputGrace = time.Second
+
+// runContext runs cmd in its own process group and kills the whole group
+// when ctx is done, so builds and tests started by a rule never outlive it.
+// Once the command has exited or been killed, killStrays (if set) gets rid of
+// anything it left running before the rest of its output is read.
+func runContext(ctx context.Context, cmd *exec.Cmd, killStrays func()) error {
+	setProcessGroup(cmd)
+
+	// exec's own pipes make Wait block until every process holding them
+	// exits, daemonized children included, so input and output go through
+	// pipes whose ends are closed here
+	output, err := redirectOutput(cmd)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		output.abort()
+		return err
+	}
+	output.started()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-done
+		err = ctx.Err()
+	}
+	if killStrays != nil {
+		killStrays()
+	}
+	output.wait(strayOutputGrace)
+	return err
+}
+
+// pipedOutput copies a command's input and output through pipes of its own
+// from and to the reader and writers the caller set
+type pipedOutput struct {
+	readers []*os.File // read ends of the output pipes
+	writers []*os.File // write ends of the output pipes, the child's
+	input   [2]*os.File
+	copies  sync.WaitGroup
+}
+
+// redirectOutput points cmd's stdin, stdout and stderr that aren't files at
+// pipes, one shared pipe when both outputs go to the same writer, as exec does
+func redirectOutput(cmd *exec.Cmd) (*pipedOutput, error) {
+	p := &pipedOutput{}
+	if _, ok := cmd.Stdin.(*os.File); !ok && cmd.Stdin != nil {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		p.input = [2]*os.File{r, w}
+		// Not waited for: a command needn't read all of its input
+		go func(stdin io.Reader) {
+			io.Copy(w, stdin)
+			w.Close()
+		}(cmd.Stdin)
+		cmd.Stdin = r
+	}
+	pipe := func(w io.Writer) (io.Writer, error) {
+		if _, ok := w.(*os.File); ok || w == nil {
+			return w, nil
+		}
+		r, pw, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		p.readers = append(p.readers, r)
+		p.writers = append(p.writers, pw)
+		p.copies.Add(1)
+		go func() {
+			defer p.copies.Done()
+			io.Copy(w, r)
+		}()
+		return pw, nil
+	}
+
+	stdout, err := pipe(cmd.Stdout)
+	if err != nil {
+		p.abort()
+		return nil, err
+	}
+	stderr := stdout
+	if cmd.Stderr != cmd.Stdout {
+		if stderr, err = pipe(cmd.Stderr); err != nil {
+			p.abort()
+			return nil, err
+		}
+	}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return p, nil
+}
+
+// started closes the pipe ends the child now holds its own copies of
+func (p *pipedOutput) started() {
+	for _, w := range p.writers {
+		w.Close()
+	}
+	if p.input[0] != nil {
+		p.input[0].Close()
+	}
+}
+
+func (p *pipedOutput) abort() {
+	p.started()
+	p.wait(0)
+}
+
+// wait lets the copies drain what is left and gives up after grace
+func (p *pipedOutput) wait(grace time.Duration) {
+	drained := make(chan struct{})
+	go func() {
+		p.copies.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(grace):
+	}
+	for _, r := range p.readers {
+		r.Close()
+	}
+	if p.input[1] != nil {
+		p.input[1].Close()
+	}
+	<-drained
+}
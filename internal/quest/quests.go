@@ -0,0 +1,209 @@
+package quest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/spf13/cobra"
+)
+
+var questNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidateQuestName rejects names that can't be a folder in .quest
+func ValidateQuestName(name string) error {
+	if !questNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid quest name '%s', use letters, digits, '.', '_' and '-'", name)
+	}
+	if name == filepath.Base(CurrentQuestFilePath) {
+		return fmt.Errorf("'%s' is reserved", name)
+	}
+	return nil
+}
+
+// SelectQuest picks the quest a command works on: the one named with
+// --quest, or else the current one. A .quest folder from before named quests
+// is moved to .quest/default first.
+func SelectQuest(cmd *cobra.Command) error {
+	if err := migrateSingleQuest(); err != nil {
+		return fmt.Errorf("failed to move the quest to %s: %w", filepath.Join(QuestFolderPath, DefaultQuestName), err)
+	}
+
+	name, _ := cmd.Flags().GetString("quest")
+	if name == "" {
+		name = CurrentQuest()
+	}
+	if err := ValidateQuestName(name); err != nil {
+		return err
+	}
+	UseQuest(name)
+	return nil
+}
+
+// migrateSingleQuest moves the files of a .quest folder that holds a single
+// quest directly into .quest/default and makes it the current quest. It
+// takes the lock that quest used, so it neither moves files from under a
+// command still using the old layout nor races another command moving them.
+func migrateSingleQuest() error {
+	if _, err := os.Stat(filepath.Join(QuestFolderPath, StateFileName)); err != nil {
+		return nil
+	}
+	lock := filepath.Join(QuestFolderPath, filepath.Base(LockFilePath))
+	unlock, err := lockAt(lock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := os.Stat(filepath.Join(QuestFolderPath, StateFileName)); err != nil {
+		// Another command moved it while this one waited
+		return nil
+	}
+	entries, err := os.ReadDir(QuestFolderPath)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(QuestFolderPath, DefaultQuestName)
+	if _, err := os.Stat(filepath.Join(target, StateFileName)); err == nil {
+		return fmt.Errorf("%s already holds a quest", target)
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == DefaultQuestName || entry.Name() == filepath.Base(lock) {
+			continue
+		}
+		if err := os.Rename(filepath.Join(QuestFolderPath, entry.Name()), filepath.Join(target, entry.Name())); err != nil {
+			return err
+		}
+	}
+	if err := SetCurrentQuest(DefaultQuestName); err != nil {
+		return err
+	}
+	format.Info(fmt.Sprintf("Moved your quest to %s, start more with 'quest begin --name <name>'", target))
+	return nil
+}
+
+// CurrentQuest returns the name of the quest commands use by default: the
+// one last begun or switched to, or the only one there is
+func CurrentQuest() string {
+	if data, err := os.ReadFile(CurrentQuestFilePath); err == nil {
+		if name := strings.TrimSpace(string(data)); name != "" {
+			return name
+		}
+	}
+	if names, _ := ListQuests(); len(names) == 1 {
+		return names[0]
+	}
+	return DefaultQuestName
+}
+
+// SetCurrentQuest makes name the quest commands use by default
+func SetCurrentQuest(name string) error {
+	return writeFileAtomic(CurrentQuestFilePath, []byte(name+"\n"), 0644)
+}
+
+// ListQuests returns the names of the quests in .quest in alphabetical order
+func ListQuests() ([]string, error) {
+	entries, err := os.ReadDir(QuestFolderPath)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(QuestFolderPath, entry.Name(), StateFileName)); err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// QuestInfo describes one of the quests in the workspace
+type QuestInfo struct {
+	Name      string `json:"name"`
+	Current   bool   `json:"current"`
+	Journey   string `json:"journey,omitempty"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+	Error     string `json:"error,omitempty"` // why the quest couldn't be read
+}
+
+func RunListQuests(cmd *cobra.Command, args []string) (err error) {
+	report := newReport(cmd, "list-quests")
+	defer func() { err = report.finish(err) }()
+
+	names, err := ListQuests()
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && len(names) == 0) {
+		format.Info("No quests yet. Run 'quest begin' to start one")
+		return exitWith(ExitNotInitialized, fs.ErrNotExist)
+	}
+	if err != nil {
+		format.Error("Failed to list quests", err)
+		return commandError(err)
+	}
+
+	format.Header("Quests")
+	current := CurrentQuest()
+	selected := QuestName
+	defer UseQuest(selected)
+
+	for _, name := range names {
+		info := QuestInfo{Name: name, Current: name == current}
+		UseQuest(name)
+		state, loadErr := LoadState()
+		plan, planErr := LoadPlan()
+		if loadErr == nil {
+			loadErr = planErr
+		}
+		if loadErr != nil {
+			info.Error = loadErr.Error()
+		} else {
+			info.Journey = plan.Journey.Name
+			info.Completed = len(state.CompletedTaskIDs)
+			info.Total = plan.NumberOfTasks
+		}
+		report.Quests = append(report.Quests, info)
+
+		mark := " "
+		if info.Current {
+			mark = format.ColorPink + "▸" + format.ColorReset
+		}
+		detail := fmt.Sprintf("%s · %d/%d tasks", info.Journey, info.Completed, info.Total)
+		if info.Error != "" {
+			detail = format.ColorRed + info.Error + format.ColorReset
+		}
+		format.Line(fmt.Sprintf("%s %s%-20s%s %s", mark, format.ColorBold, name, format.ColorReset, detail))
+	}
+	format.Newline()
+	format.CommandHint("Switch quests with", "quest switch <name>")
+	return nil
+}
+
+func RunSwitch(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := ValidateQuestName(name); err != nil {
+		format.Error("Invalid quest name", err)
+		return exitWith(ExitInternal, err)
+	}
+	UseQuest(name)
+	if _, err := os.Stat(StateFilePath); err != nil {
+		format.ErrorWithTip(fmt.Sprintf("No quest named '%s'", name), nil, "Run 'quest list-quests' to see your quests, or 'quest begin --name "+name+"' to start it")
+		return commandError(err)
+	}
+	if err := SetCurrentQuest(name); err != nil {
+		format.ErrorWithTip("Failed to switch quests", err, "Check folder permissions")
+		return exitWith(ExitInternal, err)
+	}
+	format.Success(fmt.Sprintf("Switched to quest '%s'", name))
+	format.CommandHint("See where you left off with", "quest summary")
+	return nil
+}
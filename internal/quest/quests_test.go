@@ -0,0 +1,135 @@
+package quest
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+// writeNamedQuest creates a quest called name with one task
+func writeNamedQuest(t *testing.T, name, journey string) {
+	t.Helper()
+	UseQuest(name)
+	os.MkdirAll(QuestDir, 0755)
+	plan := &types.Plan{Version: Version, NumberOfTasks: 1, Journey: types.Journey{Name: journey},
+		Chapters: []types.Chapter{{Quests: []types.Quest{{Tasks: []types.Task{{ID: "only"}}}}}}}
+	state := &types.State{Version: Version, CompletedTaskIDs: []string{}}
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectQuestMovesSingleQuest(t *testing.T) {
+	chdirTemp(t)
+	t.Cleanup(func() { UseQuest(DefaultQuestName) })
+	os.WriteFile(filepath.Join(QuestFolderName, StateFileName), []byte(`{"version": 1}`), 0644)
+	os.WriteFile(filepath.Join(QuestFolderName, PlanFileName), []byte(`{"version": 1}`), 0644)
+	os.MkdirAll(filepath.Join(QuestFolderName, "goldens"), 0755)
+
+	if err := SelectQuest(testCommand(t, "summary")); err != nil {
+		t.Fatal(err)
+	}
+	if QuestName != DefaultQuestName {
+		t.Errorf("Expected the moved quest to be selected, got %s", QuestName)
+	}
+	for _, path := range []string{StateFilePath, PlanFilePath, GoldensFolderPath} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be moved: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(QuestFolderName, StateFileName)); !os.IsNotExist(err) {
+		t.Error("Expected nothing left at the old location")
+	}
+	if CurrentQuest() != DefaultQuestName {
+		t.Errorf("Expected the moved quest to be current, got %s", CurrentQuest())
+	}
+}
+
+func TestSelectQuestWaitsToMoveSingleQuest(t *testing.T) {
+	chdirTemp(t)
+	t.Cleanup(func() { UseQuest(DefaultQuestName) })
+	old := lockTimeout
+	lockTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { lockTimeout = old })
+	os.WriteFile(filepath.Join(QuestFolderName, StateFileName), []byte(`{"version": 1}`), 0644)
+	os.WriteFile(filepath.Join(QuestFolderName, PlanFileName), []byte(`{"version": 1}`), 0644)
+
+	// A command still using the old layout holds its lock in .quest
+	legacyLock := filepath.Join(QuestFolderName, filepath.Base(LockFilePath))
+	host, _ := os.Hostname()
+	data, _ := json.Marshal(lockInfo{PID: os.Getppid(), Host: host, AcquiredAt: time.Now()})
+	os.WriteFile(legacyLock, data, 0644)
+	if err := SelectQuest(testCommand(t, "summary")); !errors.Is(err, errLocked) {
+		t.Fatalf("Expected the move to wait for the lock, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(QuestFolderName, StateFileName)); err != nil {
+		t.Errorf("Expected the quest to stay put while locked: %v", err)
+	}
+
+	os.Remove(legacyLock)
+	if err := SelectQuest(testCommand(t, "summary")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(StateFilePath); err != nil {
+		t.Errorf("Expected the quest to be moved: %v", err)
+	}
+	for _, path := range []string{legacyLock, LockFilePath} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected no lock left at %s", path)
+		}
+	}
+}
+
+func TestSelectQuest(t *testing.T) {
+	chdirTemp(t)
+	t.Cleanup(func() { UseQuest(DefaultQuestName) })
+	writeNamedQuest(t, "api", "Web API")
+
+	if err := SelectQuest(testCommand(t, "summary")); err != nil || QuestName != "api" {
+		t.Errorf("Expected the only quest to be used, got %s (%v)", QuestName, err)
+	}
+
+	writeNamedQuest(t, "tools", "CLI Tool")
+	SetCurrentQuest("tools")
+	if err := SelectQuest(testCommand(t, "summary")); err != nil || QuestName != "tools" {
+		t.Errorf("Expected the current quest to be used, got %s (%v)", QuestName, err)
+	}
+	if err := SelectQuest(testCommand(t, "summary", "--quest", "api")); err != nil || StateFilePath != filepath.Join(QuestFolderName, "api", StateFileName) {
+		t.Errorf("Expected --quest to win, got %s (%v)", StateFilePath, err)
+	}
+	for _, name := range []string{"../escape", "current", ""} {
+		if ValidateQuestName(name) == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestSwitchAndListQuests(t *testing.T) {
+	chdirTemp(t)
+	t.Cleanup(func() { UseQuest(DefaultQuestName) })
+	writeNamedQuest(t, "api", "Web API")
+	writeNamedQuest(t, "tools", "CLI Tool")
+
+	if err := RunSwitch(testCommand(t, "switch"), []string{"api"}); err != nil {
+		t.Fatal(err)
+	}
+	if CurrentQuest() != "api" {
+		t.Errorf("Expected api to be current, got %s", CurrentQuest())
+	}
+	if err := RunSwitch(testCommand(t, "switch"), []string{"missing"}); ExitCode(err) != ExitNotInitialized {
+		t.Errorf("Expected switching to a missing quest to fail, got %v", err)
+	}
+
+	report, err := captureReport(t, testCommand(t, "list-quests"), RunListQuests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Quests) != 2 || !report.Quests[0].Current || report.Quests[0].Journey != "Web API" || report.Quests[1].Total != 1 {
+		t.Errorf("Unexpected quest list: %+v", report.Quests)
+	}
+}
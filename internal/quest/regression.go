@@ -0,0 +1,172 @@
+package quest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// isGitRule reports whether a rule checks the git workflow of the task it
+// belongs to rather than the code, so it can't regress later
+func isGitRule(rule types.Rule) bool {
+	switch rule.Type {
+	case types.TypeGitCommits, types.TypeGitClean, types.TypeGitBranch, types.TypeGitMessage:
+		return true
+	}
+	return false
+}
+
+// runRegressionCheck re-validates every completed (or regressed) task plus
+// the current one and reports tasks that fail again, grouped by chapter
+func runRegressionCheck(cmd *cobra.Command, report *Report, state *types.State, plan *types.Plan) error {
+	uncomplete, _ := cmd.Flags().GetBool("uncomplete")
+
+	format.Header("Regression Check")
+
+	opts := checkOptions(cmd, state)
+	defer opts.Workspace.Close()
+	holdLockForCheck(cmd, FlattenTasks(plan), opts)
+	ctx, cancel := checkContext(cmd)
+	defer cancel()
+
+	passState := types.Pass
+	failState := types.Fail
+
+	taskIndex := -1
+	checkedCount := 0
+	failedCount := 0
+	var regressed []string
+	var events []Event
+
+	for chIdx := range plan.Chapters {
+		chapter := &plan.Chapters[chIdx]
+		headerShown := false
+
+		for qIdx := range chapter.Quests {
+			for tIdx := range chapter.Quests[qIdx].Tasks {
+				taskIndex++
+				task := &chapter.Quests[qIdx].Tasks[tIdx]
+
+				// Regressed tasks stay included after --uncomplete so they
+				// are restored once they pass again
+				completed := contains(state.CompletedTaskIDs, task.ID) || contains(state.RegressedTaskIDs, task.ID)
+				current := state.QuestStarted && taskIndex == state.CurrentTaskIndex
+				if !completed && !current {
+					continue
+				}
+
+				if !headerShown {
+					format.SectionHeader(fmt.Sprintf("Chapter %d: %s", chIdx+1, chapter.Title))
+					headerShown = true
+				}
+
+				// Git rules only make sense while the task is in progress
+				var rules []types.Rule
+				var ruleIndexes []int
+				for i, rule := range task.Validation.Rules {
+					if current || !isGitRule(rule) {
+						rules = append(rules, rule)
+						ruleIndexes = append(ruleIndexes, i)
+					}
+				}
+
+				var failures []string
+				var reported []RuleResult
+				started := time.Now()
+				EvaluateRules(ctx, rules, opts, func(i int, results []RuleResult) {
+					rule := &task.Validation.Rules[ruleIndexes[i]]
+					rule.LastState = &passState
+					reported = append(reported, results...)
+					for _, result := range results {
+						if !result.Passed {
+							rule.LastState = &failState
+							failures = append(failures, fmt.Sprintf("%s: %s", result.Name, result.Reason))
+						}
+					}
+				})
+				if errors.Is(ctx.Err(), context.Canceled) {
+					format.Newline()
+					format.Warning("Check cancelled, nothing was saved")
+					return exitWith(ExitFail, errCancelled)
+				}
+				checkedCount++
+				if len(failures) > 0 {
+					failedCount++
+				}
+
+				label := fmt.Sprintf("Task %d: %s", taskIndex+1, task.Title)
+				if current {
+					// The current task counts as checked, the same as with quest check
+					recordCheck(state, taskIndex, *task, len(failures) == 0)
+				}
+				switch {
+				case len(failures) == 0:
+					format.CheckPass(label, "")
+					if contains(state.RegressedTaskIDs, task.ID) && !contains(state.CompletedTaskIDs, task.ID) {
+						state.CompletedTaskIDs = append(state.CompletedTaskIDs, task.ID)
+					}
+					state.RegressedTaskIDs = remove(state.RegressedTaskIDs, task.ID)
+				case completed:
+					format.CheckFail(label, "- regressed")
+					format.CheckDetail(strings.Join(failures, "\n"))
+					regressed = append(regressed, task.ID)
+					if !contains(state.RegressedTaskIDs, task.ID) {
+						state.RegressedTaskIDs = append(state.RegressedTaskIDs, task.ID)
+					}
+					if uncomplete {
+						state.CompletedTaskIDs = remove(state.CompletedTaskIDs, task.ID)
+					}
+				default:
+					format.CheckFail(label, "- current task, not complete yet")
+					format.CheckDetail(strings.Join(failures, "\n"))
+				}
+
+				taskInfo := taskReport(taskIndex, *task, state)
+				taskInfo.Check = taskCheck(len(reported)-len(failures), len(failures), reported)
+				report.Tasks = append(report.Tasks, taskInfo)
+				events = append(events, checkEvent(SourceCheckAll, taskIndex, *task, taskInfo.Check.Status, time.Since(started), reported))
+			}
+		}
+	}
+
+	if err := opts.Cache.Save(); err != nil {
+		format.Warning(fmt.Sprintf("Could not save the check cache: %v", err))
+	}
+
+	if checkedCount == 0 {
+		format.Info("No completed tasks to re-check yet")
+		return nil
+	}
+
+	if failedCount == 0 {
+		format.CheckSummaryPass(checkedCount)
+	} else {
+		format.CheckSummaryFail(checkedCount-failedCount, failedCount)
+	}
+	if len(regressed) > 0 {
+		if uncomplete {
+			format.Warning(fmt.Sprintf("Marked %d task(s) as not completed", len(regressed)))
+		} else {
+			format.CommandHint("To mark them as not completed, run", "quest check --all --uncomplete")
+		}
+		format.CommandHint("Jump back to a task with", "quest jumpTo <task-number>")
+	}
+
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		format.ErrorWithTip("Failed to save quest data", err, "Check folder permissions")
+		return exitWith(ExitInternal, err)
+	}
+	for _, event := range events {
+		logEvent(event)
+	}
+	if failedCount > 0 {
+		return exitWith(ExitFail, errChecksFailed)
+	}
+	return nil
+}
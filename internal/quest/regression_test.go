@@ -0,0 +1,105 @@
+package quest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func writeRegressionQuest(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	exists := func(path string) types.Validation {
+		return types.Validation{Rules: []types.Rule{{Type: types.TypeExists, Name: path, Path: path}}}
+	}
+	plan := &types.Plan{
+		NumberOfTasks: 3,
+		Chapters: []types.Chapter{
+			{Title: "Basics", Quests: []types.Quest{{Title: "Setup", Tasks: []types.Task{
+				{ID: "go-mod", Title: "Create go.mod", Validation: exists("go.mod")},
+				{ID: "main", Title: "Create main.go", Validation: exists("main.go")},
+			}}}},
+			{Title: "Routes", Quests: []types.Quest{{Title: "Handlers", Tasks: []types.Task{
+				{ID: "handlers", Title: "Add handlers", Validation: exists("handlers.go")},
+			}}}},
+		},
+	}
+	state := &types.State{
+		Version:          Version,
+		CurrentTaskIndex: 2,
+		CompletedTaskIDs: []string{"go-mod", "main"},
+		QuestStarted:     true,
+	}
+
+	os.MkdirAll(QuestDir, 0755)
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("go.mod", []byte("module example.com/app\n"), 0644)
+}
+
+func TestRegressionCheck(t *testing.T) {
+	writeRegressionQuest(t)
+
+	// main.go was never written, so the completed "main" task regressed
+	RunCheck(testCommand(t, "check", "--all"), nil)
+
+	state, err := LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.RegressedTaskIDs) != 1 || state.RegressedTaskIDs[0] != "main" {
+		t.Errorf("Expected only 'main' to regress, got %v", state.RegressedTaskIDs)
+	}
+	if !contains(state.CompletedTaskIDs, "main") {
+		t.Error("Expected 'main' to stay completed without --uncomplete")
+	}
+
+	RunCheck(testCommand(t, "check", "--all", "--uncomplete"), nil)
+	state, _ = LoadState()
+	if contains(state.CompletedTaskIDs, "main") || !contains(state.CompletedTaskIDs, "go-mod") {
+		t.Errorf("Expected only 'main' to be un-completed, got %v", state.CompletedTaskIDs)
+	}
+
+	// Fixing it clears the regression and completes the task again
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+	RunCheck(testCommand(t, "check", "--all"), nil)
+	state, _ = LoadState()
+	if len(state.RegressedTaskIDs) != 0 {
+		t.Errorf("Expected no regressions after the fix, got %v", state.RegressedTaskIDs)
+	}
+	if !contains(state.CompletedTaskIDs, "main") {
+		t.Error("Expected 'main' to be completed again once it passes")
+	}
+}
+
+func TestRegressionCheckRecordsCurrentTask(t *testing.T) {
+	writeRegressionQuest(t)
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+
+	RunCheck(testCommand(t, "check", "--all"), nil)
+	state, _ := LoadState()
+	if contains(state.CompletedTaskIDs, "handlers") {
+		t.Error("Expected the failing current task to stay open")
+	}
+	if state.LastCheck == nil || state.LastCheck.TaskID != 2 || state.LastCheck.Status != types.CheckFail {
+		t.Errorf("Expected a failed last check of the current task, got %+v", state.LastCheck)
+	}
+
+	os.WriteFile("handlers.go", []byte("package main\n"), 0644)
+	RunCheck(testCommand(t, "check", "--all"), nil)
+	state, _ = LoadState()
+	if !contains(state.CompletedTaskIDs, "handlers") {
+		t.Errorf("Expected the passing current task to be completed, got %v", state.CompletedTaskIDs)
+	}
+	if state.LastCheck == nil || state.LastCheck.Status != types.CheckPass {
+		t.Errorf("Expected a passed last check, got %+v", state.LastCheck)
+	}
+}
@@ -0,0 +1,297 @@
+package quest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Reporter kinds for check --report
+const (
+	ReporterJUnit = "junit"
+	ReporterSARIF = "sarif"
+)
+
+// reporterSpec is one --report kind=path
+type reporterSpec struct {
+	Kind string
+	Path string
+}
+
+// parseReporters reads the --report values, such as junit=out/quest.xml
+func parseReporters(values []string) ([]reporterSpec, error) {
+	var specs []reporterSpec
+	for _, value := range values {
+		kind, path, ok := strings.Cut(value, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("report '%s' should look like %s=path or %s=path", value, ReporterJUnit, ReporterSARIF)
+		}
+		switch kind {
+		case ReporterJUnit, ReporterSARIF:
+		default:
+			return nil, fmt.Errorf("unknown report kind '%s', use %s or %s", kind, ReporterJUnit, ReporterSARIF)
+		}
+		specs = append(specs, reporterSpec{Kind: kind, Path: userPath(path)})
+	}
+	return specs, nil
+}
+
+// writeReporters writes every requested report from the results of a check
+func writeReporters(specs []reporterSpec, report *Report) error {
+	for _, spec := range specs {
+		var data []byte
+		var err error
+		switch spec.Kind {
+		case ReporterJUnit:
+			data, err = junitReport(report)
+		case ReporterSARIF:
+			data, err = sarifReport(report)
+		}
+		if err != nil {
+			return fmt.Errorf("%s report: %w", spec.Kind, err)
+		}
+		if dir := filepath.Dir(spec.Path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("%s report: %w", spec.Kind, err)
+			}
+		}
+		if err := os.WriteFile(spec.Path, data, 0644); err != nil {
+			return fmt.Errorf("%s report: %w", spec.Kind, err)
+		}
+	}
+	return nil
+}
+
+// checkedTasks are the tasks of a report that were checked
+func checkedTasks(report *Report) []TaskReport {
+	var tasks []TaskReport
+	if report.Task != nil && report.Task.Check != nil {
+		tasks = append(tasks, *report.Task)
+	}
+	for _, task := range report.Tasks {
+		if task.Check != nil {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitReport has a test suite per checked task and a test case per rule
+// result
+func junitReport(report *Report) ([]byte, error) {
+	suites := junitTestSuites{Name: "quest"}
+	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05")
+
+	for _, task := range checkedTasks(report) {
+		suite := junitTestSuite{
+			Name:      fmt.Sprintf("Task %d: %s", task.Number, task.Title),
+			Timestamp: timestamp,
+		}
+		for _, result := range task.Check.Results {
+			testCase := junitTestCase{
+				Name:      result.Name,
+				ClassName: "quest." + task.ID,
+				File:      result.File,
+				Line:      result.Line,
+			}
+			if result.Passed {
+				testCase.SystemOut = result.Reason
+			} else {
+				testCase.Failure = &junitFailure{Message: result.Reason, Text: result.Detail}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// sarifAnnotationRule is the rule ID of AI annotations from check --annotate
+	sarifAnnotationRule = "quest/annotation"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name,omitempty"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Kind      string          `json:"kind,omitempty"` // "fail" or "pass"
+	Level     string          `json:"level"`          // "error", "warning", "note" or "none"
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifReport turns failing rule results, passing results with file
+// evidence and AI annotations into SARIF results
+func sarifReport(report *Report) ([]byte, error) {
+	driver := sarifDriver{Name: "quest", InformationURI: "https://github.com/jovanpet/quest", Rules: []sarifRule{}}
+	results := []sarifResult{}
+	ruleIDs := map[string]bool{}
+
+	for _, task := range checkedTasks(report) {
+		for _, result := range task.Check.Results {
+			if result.Passed && result.File == "" {
+				continue
+			}
+			ruleID := task.ID + "/" + result.Name
+			if !ruleIDs[ruleID] {
+				ruleIDs[ruleID] = true
+				driver.Rules = append(driver.Rules, sarifRule{
+					ID:               ruleID,
+					Name:             result.Name,
+					ShortDescription: sarifMessage{Text: fmt.Sprintf("Task %d: %s", task.Number, task.Title)},
+				})
+			}
+
+			sarif := sarifResult{RuleID: ruleID, Kind: "fail", Level: "error"}
+			if result.Passed {
+				sarif.Kind = "pass"
+				sarif.Level = "none"
+			}
+			sarif.Message.Text = strings.TrimSpace(strings.TrimPrefix(result.Reason, "- "))
+			if sarif.Message.Text == "" {
+				sarif.Message.Text = result.Name
+			}
+			if result.File != "" {
+				sarif.Locations = []sarifLocation{sarifFileLocation(result.File, result.Line)}
+			}
+			results = append(results, sarif)
+		}
+	}
+
+	for _, hint := range report.Hints {
+		if !ruleIDs[sarifAnnotationRule] {
+			ruleIDs[sarifAnnotationRule] = true
+			driver.Rules = append(driver.Rules, sarifRule{
+				ID:               sarifAnnotationRule,
+				ShortDescription: sarifMessage{Text: "AI review comment from quest check --annotate"},
+			})
+		}
+		level := "note"
+		switch hint.Type {
+		case "error":
+			level = "error"
+		case "warning":
+			level = "warning"
+		}
+		results = append(results, sarifResult{
+			RuleID:    sarifAnnotationRule,
+			Level:     level,
+			Message:   sarifMessage{Text: hint.Comment},
+			Locations: []sarifLocation{sarifFileLocation(hint.File, hint.Line)},
+		})
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func sarifFileLocation(file string, line int) sarifLocation {
+	location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(file)},
+	}}
+	if line > 0 {
+		location.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+	}
+	return location
+}
@@ -0,0 +1,116 @@
+package quest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestParseReporters(t *testing.T) {
+	specs, err := parseReporters([]string{"junit=out/quest.xml", "sarif=quest.sarif"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 2 || specs[0].Kind != ReporterJUnit || specs[1].Path != "quest.sarif" {
+		t.Errorf("Unexpected reporters %+v", specs)
+	}
+
+	for _, value := range []string{"junit", "junit=", "html=out.html"} {
+		if _, err := parseReporters([]string{value}); err == nil {
+			t.Errorf("Expected an error for --report %s", value)
+		}
+	}
+}
+
+func TestCheckWritesReporters(t *testing.T) {
+	writeRegressionQuest(t)
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+
+	junitPath := filepath.Join("reports", "quest.xml")
+	sarifPath := filepath.Join("reports", "quest.sarif")
+	cmd := testCommand(t, "check", "--all", "--report", "junit="+junitPath, "--report", "sarif="+sarifPath)
+	if got := ExitCode(RunCheck(cmd, nil)); got != ExitFail {
+		t.Fatalf("Expected exit code %d for the failing current task, got %d", ExitFail, got)
+	}
+
+	data, err := os.ReadFile(junitPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatalf("Invalid JUnit XML: %v", err)
+	}
+	if len(suites.Suites) != 3 || suites.Tests != 3 || suites.Failures != 1 {
+		t.Fatalf("Expected 3 suites with 3 tests and 1 failure, got %d, %d and %d", len(suites.Suites), suites.Tests, suites.Failures)
+	}
+	failing := suites.Suites[2].Cases[0]
+	if failing.Failure == nil || failing.ClassName != "quest.handlers" || failing.File != "handlers.go" {
+		t.Errorf("Expected handlers.go to fail, got %+v", failing)
+	}
+
+	data, err = os.ReadFile(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("Invalid SARIF: %v", err)
+	}
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected SARIF log %+v", log)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 3 {
+		t.Fatalf("Expected a result for each file rule, got %d", len(results))
+	}
+	last := results[2]
+	if last.Level != "error" || last.RuleID != "handlers/handlers.go" || last.Locations[0].PhysicalLocation.ArtifactLocation.URI != "handlers.go" {
+		t.Errorf("Expected an error located in handlers.go, got %+v", last)
+	}
+	if results[0].Kind != "pass" {
+		t.Errorf("Expected passing evidence to be a pass result, got %+v", results[0])
+	}
+}
+
+func TestSARIFAnnotationsAndLines(t *testing.T) {
+	report := &Report{
+		Task: &TaskReport{Number: 1, ID: "hello", Title: "Say hello", Check: &TaskCheck{Results: []RuleResult{
+			{Name: "greeting", Passed: true, Reason: "- main.go - contains 'Hello'", File: "main.go", Line: 4},
+		}}},
+		Hints: []HintReport{{File: "main.go", Line: 7, Type: "warning", Comment: "handle the error"}},
+	}
+	data, err := sarifReport(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("Expected the rule and the annotation, got %d results", len(results))
+	}
+	if region := results[0].Locations[0].PhysicalLocation.Region; region == nil || region.StartLine != 4 {
+		t.Errorf("Expected the matching line 4, got %+v", region)
+	}
+	if results[1].RuleID != sarifAnnotationRule || results[1].Level != "warning" {
+		t.Errorf("Expected a warning annotation, got %+v", results[1])
+	}
+}
+
+func TestFileContainsEvidence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	os.WriteFile(path, []byte("package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"), 0644)
+
+	file, line := ruleEvidence(types.Rule{Type: types.TypeFileContainsAny, Glob: filepath.Join(dir, "*.go"), Any: []string{"hello"}}, true)
+	if file != filepath.ToSlash(path) || line != 4 {
+		t.Errorf("Expected %s:4, got %s:%d", path, file, line)
+	}
+}
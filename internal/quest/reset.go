@@ -0,0 +1,191 @@
+package quest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// starterContent returns what a file of the task held when the task started:
+// its content in the first start snapshot, or the stub quest next creates.
+// Without a snapshot of the file, ok is false and only a missing file may be
+// recreated from the stub.
+func starterContent(start *Snapshot, path string) (content []byte, source string, ok bool, err error) {
+	if start != nil {
+		if hash, found := start.Files[path]; found {
+			content, err := readObject(hash)
+			return content, "as the task started", true, err
+		}
+	}
+	return []byte(artifactStub), "with the stub", false, nil
+}
+
+// resetChanges lists the task's starter files that differ from how the task
+// started, with the content to put back in Before. Files that exist but were
+// never snapshotted are returned in kept: what they started as is unknown.
+func resetChanges(task types.Task) (changes []FileChange, kept []string, err error) {
+	start, err := snapshotFor(task.ID, SnapshotStart)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, file := range task.Files {
+		path := filepath.ToSlash(filepath.Clean(file))
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		change := FileChange{Path: path, Existed: true}
+		var known bool
+		if change.Before, _, known, err = starterContent(start, path); err != nil {
+			return nil, nil, err
+		}
+		after, err := os.ReadFile(filepath.FromSlash(path))
+		if err == nil {
+			change.Exists = true
+			change.After = after
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, nil, err
+		}
+		if change.Exists && !known {
+			kept = append(kept, path)
+			continue
+		}
+		if change.Exists && bytes.Equal(change.Before, change.After) {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, kept, nil
+}
+
+func RunReset(cmd *cobra.Command, args []string) error {
+	unlock, err := lockForUpdate()
+	if err != nil {
+		return commandError(err)
+	}
+	defer unlock()
+
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
+		return commandError(err)
+	}
+
+	tasks := FlattenTasks(plan)
+	index := state.CurrentTaskIndex
+	if len(args) == 1 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > len(tasks) {
+			format.Error(fmt.Sprintf("Task index out of range (1-%d)", len(tasks)), nil)
+			return exitWith(ExitInternal, errors.New("task index out of range"))
+		}
+		index = n - 1
+	}
+	if index >= len(tasks) {
+		format.ErrorWithTip("The quest is finished", nil, "Name the task to reset, e.g. 'quest reset 1'")
+		return exitWith(ExitInternal, errors.New("no current task"))
+	}
+	task := tasks[index]
+	current := index == state.CurrentTaskIndex
+
+	changes, kept, err := resetChanges(task)
+	if err != nil {
+		format.Error("Failed to compare with the start of the task", err)
+		return exitWith(ExitInternal, err)
+	}
+	start, _ := snapshotFor(task.ID, SnapshotStart)
+
+	// Everything that is about to change, so the learner knows what they lose
+	var lines []string
+	for _, change := range changes {
+		action := "overwrite"
+		if !change.Exists {
+			action = "recreate "
+		}
+		_, source, _, _ := starterContent(start, change.Path)
+		lines = append(lines, fmt.Sprintf("%s%s%s %s %s%s%s", format.ColorDim, action, format.ColorReset, change.Path, format.ColorDim, source, format.ColorReset))
+	}
+	if contains(state.CompletedTaskIDs, task.ID) || contains(state.RegressedTaskIDs, task.ID) {
+		lines = append(lines, fmt.Sprintf("%smark%s task %d as not completed", format.ColorDim, format.ColorReset, index+1))
+	}
+	checked := 0
+	for _, rule := range task.Validation.Rules {
+		if rule.LastState != nil {
+			checked++
+		}
+	}
+	if checked > 0 {
+		lines = append(lines, fmt.Sprintf("%sclear%s the last results of %d rule(s)", format.ColorDim, format.ColorReset, checked))
+	}
+	if current && state.ExplainCount > 0 {
+		lines = append(lines, fmt.Sprintf("%sreset%s the hint level, %d explain(s) so far", format.ColorDim, format.ColorReset, state.ExplainCount))
+	}
+	if len(lines) == 0 {
+		format.Success(fmt.Sprintf("Task %d is already as fresh as it gets", index+1))
+		for _, path := range kept {
+			format.Dim(fmt.Sprintf("Kept %s as is, there is no snapshot of how the task started", path))
+		}
+		return nil
+	}
+	for _, path := range kept {
+		lines = append(lines, fmt.Sprintf("%skeep%s      %s %sno snapshot of how the task started%s", format.ColorDim, format.ColorReset, path, format.ColorDim, format.ColorReset))
+	}
+
+	if !confirmChanges(cmd, fmt.Sprintf("Reset task %d: %s", index+1, task.Title), lines, "Reset this task? (y/N)") {
+		return exitWith(ExitFail, errCancelled)
+	}
+
+	if len(changes) > 0 {
+		if err := takeSnapshot(state, task, SnapshotRestore); err != nil {
+			format.ErrorWithTip("Could not snapshot your files before resetting", err, "Nothing was changed")
+			return exitWith(ExitInternal, err)
+		}
+		if err := writeChanges(changes); err != nil {
+			format.ErrorWithTip("Failed to reset files", err, fmt.Sprintf("Undo with 'quest restore --task %d --at %s'", index+1, SnapshotRestore))
+			return exitWith(ExitInternal, err)
+		}
+	}
+
+	// The rules are shared with the plan, so this clears them there too
+	for i := range task.Validation.Rules {
+		task.Validation.Rules[i].LastState = nil
+	}
+	state.CompletedTaskIDs = remove(state.CompletedTaskIDs, task.ID)
+	state.RegressedTaskIDs = remove(state.RegressedTaskIDs, task.ID)
+	if current {
+		state.ExplainCount = 0
+		state.LastCheck = &types.CheckResult{
+			TaskID:    index,
+			Status:    types.CheckFail,
+			Timestamp: time.Now(),
+		}
+		markTaskStarted(state)
+	}
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		format.ErrorWithTip("Failed to save quest data", err, "Check folder permissions")
+		return exitWith(ExitInternal, err)
+	}
+	logEvent(Event{Type: EventReset, Task: index + 1, TaskID: task.ID})
+
+	format.Success(fmt.Sprintf("Task %d reset, time for a clean run", index+1))
+	if len(changes) > 0 {
+		format.CommandHint("Want your code back? Run", fmt.Sprintf("quest restore --task %d --at %s", index+1, SnapshotRestore))
+	}
+	if current {
+		format.CommandHint("When ready, run", "quest check")
+	} else {
+		format.CommandHint("Work on it now with", fmt.Sprintf("quest jumpTo %d", index+1))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package quest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestResetTask(t *testing.T) {
+	chdirTemp(t)
+	pass := types.Pass
+	task := types.Task{ID: "handlers", Files: []string{"handlers.go", "routes.go"}, Artifacts: []string{"handlers.go", "routes.go"},
+		Validation: types.Validation{Rules: []types.Rule{{Type: types.TypeExists, Path: "handlers.go", LastState: &pass}}}}
+	plan := &types.Plan{Version: Version, NumberOfTasks: 1,
+		Chapters: []types.Chapter{{Quests: []types.Quest{{Tasks: []types.Task{task}}}}}}
+	state := &types.State{Version: Version, CompletedTaskIDs: []string{"handlers"}, ExplainCount: 2}
+	os.WriteFile("handlers.go", []byte("package main // starter\n"), 0644)
+	takeSnapshot(state, task, SnapshotStart)
+	os.WriteFile("handlers.go", []byte("package main // solved\n"), 0644)
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RunReset(testCommand(t, "reset", "--yes"), []string{"1"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile("handlers.go"); string(data) != "package main // starter\n" {
+		t.Errorf("Expected the file as the task started, got %q", data)
+	}
+	if data, _ := os.ReadFile("routes.go"); string(data) != artifactStub {
+		t.Errorf("Expected a file without snapshot to get its stub, got %q", data)
+	}
+
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.CompletedTaskIDs) != 0 || state.ExplainCount != 0 {
+		t.Errorf("Expected the task to be forgotten, got %+v", state)
+	}
+	if plan.Chapters[0].Quests[0].Tasks[0].Validation.Rules[0].LastState != nil {
+		t.Error("Expected the rule results to be cleared")
+	}
+	restore, err := snapshotFor("handlers", SnapshotRestore)
+	if err != nil || len(restore.Files) != 1 {
+		t.Errorf("Expected the solved file to be snapshotted first, got %+v (%v)", restore, err)
+	}
+}
+
+func TestResetKeepsFilesWithoutSnapshot(t *testing.T) {
+	chdirTemp(t)
+	tasks := []types.Task{
+		{ID: "setup", Files: []string{"main.go"}},
+		{ID: "handlers", Files: []string{"handlers.go", "routes.go"}},
+	}
+	plan := &types.Plan{Version: Version, NumberOfTasks: 2,
+		Chapters: []types.Chapter{{Quests: []types.Quest{{Tasks: tasks}}}}}
+	state := &types.State{Version: Version, CompletedTaskIDs: []string{"handlers"}}
+	os.WriteFile("handlers.go", []byte("package main // mine\n"), 0644)
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing recorded how handlers.go started, so it must not become a stub
+	if err := RunReset(testCommand(t, "reset", "--yes"), []string{"2"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile("handlers.go"); string(data) != "package main // mine\n" {
+		t.Errorf("Expected a file without snapshot to be kept, got %q", data)
+	}
+	if data, _ := os.ReadFile("routes.go"); string(data) != artifactStub {
+		t.Errorf("Expected a missing file to get its stub, got %q", data)
+	}
+
+	// Jumping to the task records how it starts, so reset can go back to it
+	if err := RunJumpTo(testCommand(t, "jumpTo"), []string{"2"}); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("handlers.go", []byte("package main // changed\n"), 0644)
+	if err := RunReset(testCommand(t, "reset", "--yes"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile("handlers.go"); string(data) != "package main // mine\n" {
+		t.Errorf("Expected the file as it was when jumping to the task, got %q", data)
+	}
+}
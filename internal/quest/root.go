@@ -0,0 +1,114 @@
+package quest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/spf13/cobra"
+)
+
+// invocationDir is where quest was started, before EnterQuestRoot moved to
+// the quest root. Paths the user typed are relative to it.
+var invocationDir string
+
+// FindQuestRoot walks up from dir to the nearest directory holding a
+// .quest folder, the way git finds its repository
+func FindQuestRoot(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		info, err := os.Stat(filepath.Join(dir, QuestFolderName))
+		if err == nil && info.IsDir() {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no %s folder here or in any parent directory: %w", QuestFolderName, fs.ErrNotExist)
+		}
+		dir = parent
+	}
+}
+
+// EnterQuestRoot makes the quest root the working directory, so rules,
+// artifacts and AI file paths all resolve against it wherever quest was run.
+// The root is --root when given, otherwise the nearest directory with a
+// .quest folder. Without one quest stays put, ready for quest begin. A quest
+// whose code lives further down moves on with EnterWorkspace.
+func EnterQuestRoot(cmd *cobra.Command) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	invocationDir = wd
+
+	root, _ := cmd.Flags().GetString("root")
+	if root == "" {
+		root, err = FindQuestRoot(wd)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := os.Chdir(root); err != nil {
+		return fmt.Errorf("can't use %s as the quest root: %w", root, err)
+	}
+	root, err = os.Getwd()
+	if err != nil {
+		return err
+	}
+	setQuestRoot(root)
+	return nil
+}
+
+// EnterWorkspace moves from the quest root into the folder holding the
+// selected quest's code, like services/api in a monorepo whose .quest sits
+// at the top. quest begin records it as the folder it was run in.
+func EnterWorkspace() {
+	if questRoot == "" {
+		return
+	}
+	// Only the workspace is needed here, the state is loaded properly later
+	var state struct {
+		Workspace string `json:"workspace"`
+	}
+	data, err := os.ReadFile(StateFilePath)
+	if err != nil || json.Unmarshal(data, &state) != nil || state.Workspace == "" {
+		return
+	}
+	dir := filepath.Join(questRoot, filepath.FromSlash(state.Workspace))
+	if err := os.Chdir(dir); err != nil {
+		format.Warning(fmt.Sprintf("The workspace of quest '%s' is gone (%v), working in %s instead", QuestName, err, questRoot))
+	}
+}
+
+// workspaceOf returns dir relative to the quest root, or "" when it is the
+// root itself or outside of it
+func workspaceOf(dir string) string {
+	if questRoot == "" || dir == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(questRoot, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// userPath resolves a relative path the user typed against the directory
+// quest was started in rather than the quest root
+func userPath(path string) string {
+	if filepath.IsAbs(path) || invocationDir == "" {
+		return path
+	}
+	return filepath.Join(invocationDir, path)
+}
@@ -0,0 +1,161 @@
+package quest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+// workingDir returns the working directory with symlinks resolved, so it
+// compares equal to a resolved temporary directory
+func workingDir(t *testing.T) string {
+	t.Helper()
+	wd, _ := os.Getwd()
+	wd, err := filepath.EvalSymlinks(wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wd
+}
+
+func TestEnterQuestRoot(t *testing.T) {
+	dir, _ := filepath.EvalSymlinks(t.TempDir())
+	wd, _ := os.Getwd()
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		invocationDir = ""
+		setQuestRoot("")
+	})
+	nested := filepath.Join(dir, "internal", "handlers")
+	os.MkdirAll(nested, 0755)
+	os.MkdirAll(filepath.Join(dir, QuestFolderName), 0755)
+	os.Chdir(nested)
+
+	if err := EnterQuestRoot(testCommand(t, "check")); err != nil {
+		t.Fatal(err)
+	}
+	if got := workingDir(t); got != dir {
+		t.Errorf("Expected to move up to %s, got %s", dir, got)
+	}
+	if got := userPath("out/quest.xml"); got != filepath.Join(nested, "out", "quest.xml") {
+		t.Errorf("Expected typed paths to stay relative to where quest ran, got %s", got)
+	}
+
+	os.Chdir(nested)
+	if err := EnterQuestRoot(testCommand(t, "check", "--root", filepath.Join(dir, "internal"))); err != nil {
+		t.Fatal(err)
+	}
+	if got := workingDir(t); got != filepath.Join(dir, "internal") {
+		t.Errorf("Expected --root to win, got %s", got)
+	}
+	if err := EnterQuestRoot(testCommand(t, "check", "--root", filepath.Join(dir, "missing"))); err == nil {
+		t.Error("Expected a missing --root to fail")
+	}
+}
+
+func TestEnterWorkspace(t *testing.T) {
+	dir, _ := filepath.EvalSymlinks(t.TempDir())
+	wd, _ := os.Getwd()
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		invocationDir = ""
+		setQuestRoot("")
+	})
+	service := filepath.Join(dir, "services", "api")
+	os.MkdirAll(service, 0755)
+	os.Chdir(service)
+	UseQuest(DefaultQuestName)
+	os.MkdirAll(filepath.Join(dir, QuestFolderName, DefaultQuestName), 0755)
+
+	if err := EnterQuestRoot(testCommand(t, "check")); err != nil {
+		t.Fatal(err)
+	}
+	workspace := workspaceOf(invocationDir)
+	if workspace != "services/api" {
+		t.Fatalf("Expected quest begin to record services/api, got %q", workspace)
+	}
+	state := &types.State{Version: Version, CompletedTaskIDs: []string{}, Workspace: workspace}
+	if err := UploadState(state); err != nil {
+		t.Fatal(err)
+	}
+
+	EnterWorkspace()
+	if got := workingDir(t); got != service {
+		t.Errorf("Expected to work in %s, got %s", service, got)
+	}
+	if _, err := LoadState(); err != nil {
+		t.Errorf("Expected the quest to load from its workspace, got %v", err)
+	}
+
+	// A quest whose workspace is gone keeps working from the root
+	os.Chdir(dir)
+	os.RemoveAll(filepath.Join(dir, "services"))
+	EnterWorkspace()
+	if got := workingDir(t); got != dir {
+		t.Errorf("Expected to stay in the root, got %s", got)
+	}
+	if got := workspaceOf(filepath.Dir(dir)); got != "" {
+		t.Errorf("Expected a folder outside the root not to be a workspace, got %q", got)
+	}
+}
+
+func TestEnterQuestRootWithoutQuest(t *testing.T) {
+	dir, _ := filepath.EvalSymlinks(t.TempDir())
+	wd, _ := os.Getwd()
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		invocationDir = ""
+	})
+	os.Chdir(dir)
+
+	if _, err := FindQuestRoot(dir); err == nil {
+		t.Skip("a parent of the temporary directory holds a .quest folder")
+	}
+	if err := EnterQuestRoot(testCommand(t, "check")); err != nil {
+		t.Fatal(err)
+	}
+	if got := workingDir(t); got != dir {
+		t.Errorf("Expected to stay in %s for quest begin, got %s", dir, got)
+	}
+}
+
+func TestLanguageServerInitializeEntersWorkspace(t *testing.T) {
+	dir, _ := filepath.EvalSymlinks(t.TempDir())
+	wd, _ := os.Getwd()
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		invocationDir = ""
+		setQuestRoot("")
+	})
+	service := filepath.Join(dir, "services", "api")
+	os.MkdirAll(filepath.Join(service, "handlers"), 0755)
+	UseQuest(DefaultQuestName)
+	os.MkdirAll(filepath.Join(dir, QuestFolderName, DefaultQuestName), 0755)
+	os.Chdir(dir)
+	setQuestRoot(dir)
+	state := &types.State{Version: Version, CompletedTaskIDs: []string{}, Workspace: "services/api"}
+	if err := UploadState(state); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server was started elsewhere, with a root of its own
+	elsewhere, _ := filepath.EvalSymlinks(t.TempDir())
+	os.Chdir(elsewhere)
+	setQuestRoot(elsewhere)
+
+	s := newLanguageServer(testCommand(t, "lsp"), &fakeLSPClient{})
+	if err := s.Initialize(filepath.Join(service, "handlers")); err != nil {
+		t.Fatal(err)
+	}
+	if questRoot != dir {
+		t.Errorf("Expected the quest root %s, got %s", dir, questRoot)
+	}
+	if got := workingDir(t); got != service {
+		t.Errorf("Expected to work in %s, got %s", service, got)
+	}
+	if _, err := LoadState(); err != nil {
+		t.Errorf("Expected the quest to load from its workspace, got %v", err)
+	}
+}
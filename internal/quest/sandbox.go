@@ -0,0 +1,250 @@
+package quest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Limits applied to every process started in a sandbox, where supported.
+// sandboxProcesses caps the processes and threads a sandbox may run at once,
+// on top of those already running, so a fork bomb stops there.
+const (
+	sandboxCPUSeconds = 120
+	sandboxMemoryKB   = 4 << 20 // 4 GiB of address space
+	sandboxProcesses  = 1024
+)
+
+// sandboxMarker is set in the environment of sandboxed processes so that
+// strays can be found and killed after the run
+const sandboxMarker = "QUEST_SANDBOX"
+
+// sandbox runs learner code in a throwaway copy of the workspace, so builds,
+// tests and servers can't change the real files, with a HOME and TMPDIR of
+// its own
+type sandbox struct {
+	Dir    string // the workspace copy
+	root   string
+	id     string
+	cgroup string // pids cgroup of the sandbox, "" where none can be created
+}
+
+// workspaceCopy is a copy of the workspace made once per check, the first
+// time a rule needs one, and shared by the sandboxes of all its rules. Every
+// rule sees the files as they were when the check started.
+type workspaceCopy struct {
+	once sync.Once
+	root string
+	err  error
+}
+
+func (w *workspaceCopy) dir() (string, error) {
+	w.once.Do(func() {
+		w.root, w.err = os.MkdirTemp("", "quest-workspace-")
+		if w.err != nil {
+			w.err = fmt.Errorf("failed to create sandbox: %w", w.err)
+			return
+		}
+		if err := copyWorkspace(".", w.root); err != nil {
+			w.err = fmt.Errorf("failed to copy workspace into sandbox: %w", err)
+		}
+	})
+	return w.root, w.err
+}
+
+// Close deletes the copy once the check is done
+func (w *workspaceCopy) Close() {
+	if w != nil && w.root != "" {
+		os.RemoveAll(w.root)
+	}
+}
+
+// newSandbox prepares a sandbox working in the shared copy of the workspace,
+// or in a copy of its own when shared is nil
+func newSandbox(shared *workspaceCopy) (*sandbox, error) {
+	warnUnappliedLimits()
+	root, err := os.MkdirTemp("", "quest-sandbox-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+
+	idBytes := make([]byte, 8)
+	rand.Read(idBytes)
+	s := &sandbox{
+		Dir:  filepath.Join(root, "workspace"),
+		root: root,
+		id:   hex.EncodeToString(idBytes),
+	}
+	s.cgroup = newPidsCgroup(s.id)
+
+	for _, dir := range []string{filepath.Join(root, "home"), filepath.Join(root, "tmp")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			s.Close()
+			return nil, fmt.Errorf("failed to create sandbox: %w", err)
+		}
+	}
+	if shared != nil {
+		if s.Dir, err = shared.dir(); err != nil {
+			s.Close()
+			return nil, err
+		}
+		return s, nil
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	if err := copyWorkspace(".", s.Dir); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to copy workspace into sandbox: %w", err)
+	}
+	return s, nil
+}
+
+// Command prepares name to run inside the sandbox with resource limits and
+// an isolated HOME, TMPDIR and GOCACHE
+func (s *sandbox) Command(name string, args ...string) (*exec.Cmd, error) {
+	if !strings.ContainsRune(name, os.PathSeparator) && !strings.ContainsRune(name, '/') {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return nil, err
+		}
+		name = path
+	}
+
+	cmd := limitedCommand(s.cgroup, name, args)
+	cmd.Dir = s.Dir
+	cmd.Env = s.env()
+	return cmd, nil
+}
+
+// Run runs cmd, prepared by Command, until it exits or ctx is done, then
+// kills whatever it left running in the sandbox so a daemonized child holding
+// its output can't keep the rule waiting
+func (s *sandbox) Run(ctx context.Context, cmd *exec.Cmd) error {
+	return runContext(ctx, cmd, func() { killSandboxOrphans(s) })
+}
+
+// Close kills anything still running from the sandbox and deletes it, but
+// not a workspace copy it shares
+func (s *sandbox) Close() {
+	killSandboxOrphans(s)
+	removePidsCgroup(s.cgroup)
+	os.RemoveAll(s.root)
+}
+
+func (s *sandbox) env() []string {
+	isolated := map[string]string{
+		"HOME":        filepath.Join(s.root, "home"),
+		"USERPROFILE": filepath.Join(s.root, "home"),
+		"TMPDIR":      filepath.Join(s.root, "tmp"),
+		"GOCACHE":     sandboxGoCache(s.root),
+		"GOTOOLCHAIN": "local",
+		sandboxMarker: s.id,
+	}
+	// Keep downloaded modules shared, there is no reason to fetch them again
+	for key, value := range goModuleEnv() {
+		isolated[key] = value
+	}
+
+	var env []string
+	for _, kv := range os.Environ() {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if _, ok := isolated[key]; !ok {
+			env = append(env, kv)
+		}
+	}
+	for key, value := range isolated {
+		env = append(env, key+"="+value)
+	}
+	return env
+}
+
+// sandboxGoCache is a build cache used only by sandboxed runs. It lives in
+// the user cache directory so builds stay fast between checks.
+func sandboxGoCache(root string) string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "quest", "gocache")
+	}
+	return filepath.Join(root, "gocache")
+}
+
+var (
+	goModuleEnvOnce sync.Once
+	goModuleEnvVars map[string]string
+)
+
+// goModuleEnv returns the real GOPATH and GOMODCACHE, which would otherwise
+// move along with HOME
+func goModuleEnv() map[string]string {
+	goModuleEnvOnce.Do(func() {
+		goModuleEnvVars = map[string]string{}
+		out, err := exec.Command("go", "env", "GOPATH", "GOMODCACHE").Output()
+		if err != nil {
+			return
+		}
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		if len(lines) == 2 {
+			goModuleEnvVars["GOPATH"] = strings.TrimSpace(lines[0])
+			goModuleEnvVars["GOMODCACHE"] = strings.TrimSpace(lines[1])
+		}
+	})
+	return goModuleEnvVars
+}
+
+// copyWorkspace copies src into dst, skipping quest's own data and folders
+// that are never needed to build the learner's code
+func copyWorkspace(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			if rel != "." && (info.Name() == QuestFolderName || info.Name() == ".git" || info.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,271 @@
+//go:build linux
+
+package quest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+)
+
+// sandboxLimits are applied with ulimit, in this order
+var sandboxLimits = []struct {
+	name  string
+	flag  string
+	value int
+}{
+	{"CPU time", "-t", sandboxCPUSeconds},
+	{"memory", "-v", sandboxMemoryKB},
+}
+
+// sandboxScript joins the sandbox's pids cgroup, or failing that caps the
+// user's processes at what already runs plus sandboxProcesses (-u in bash, -p
+// in dash), then applies the other limits and starts the command. Its
+// arguments are the cgroup.procs file, the process limit and the command.
+const sandboxScript = `{ [ -n "$1" ] && echo $$ > "$1"; } 2>/dev/null || ulimit -u "$2" 2>/dev/null || ulimit -p "$2" 2>/dev/null; shift 2; `
+
+// limitedCommand runs name through sh so the limits are applied before the
+// learner's code starts. A limit the shell refuses is skipped quietly here,
+// the learner's output must stay clean, and reported once by
+// warnUnappliedLimits instead.
+func limitedCommand(cgroup, name string, args []string) *exec.Cmd {
+	var script strings.Builder
+	script.WriteString(sandboxScript)
+	for _, limit := range sandboxLimits {
+		fmt.Fprintf(&script, "ulimit %s %d 2>/dev/null; ", limit.flag, limit.value)
+	}
+	script.WriteString(`exec "$@"`)
+
+	procs := ""
+	if cgroup != "" {
+		procs = filepath.Join(cgroup, "cgroup.procs")
+	}
+	nproc := strconv.Itoa(userThreads() + sandboxProcesses)
+	return exec.Command("/bin/sh", append([]string{"-c", script.String(), "quest-sandbox", procs, nproc, name}, args...)...)
+}
+
+var unappliedLimitsOnce sync.Once
+
+// warnUnappliedLimits tells once per run which limits can't be applied, for
+// example because a hard limit is already lower, so code runs without them
+func warnUnappliedLimits() {
+	unappliedLimitsOnce.Do(func() {
+		for _, limit := range sandboxLimits {
+			out, err := exec.Command("/bin/sh", "-c", fmt.Sprintf("ulimit %s %d", limit.flag, limit.value)).CombinedOutput()
+			if err != nil {
+				reason := strings.TrimSpace(string(out))
+				if reason == "" {
+					reason = err.Error()
+				}
+				format.Warning(fmt.Sprintf("Checks run your code without a %s limit: %s", limit.name, reason))
+			}
+		}
+		if !processesCapped() {
+			format.Warning("Checks run your code without a process limit: root ignores the user process limit and no pids cgroup can be created")
+		}
+	})
+}
+
+// processesCapped reports whether sandboxes here get a process limit. The
+// user process limit binds everyone but root, a pids cgroup binds root too.
+func processesCapped() bool {
+	if probe := newPidsCgroup(fmt.Sprintf("probe-%d", os.Getpid())); probe != "" {
+		removePidsCgroup(probe)
+		return true
+	}
+	return os.Geteuid() != 0
+}
+
+// userThreads counts the threads running under this user, which is what the
+// user process limit counts too
+func userThreads() int {
+	uid := strconv.Itoa(os.Getuid())
+	entries, _ := filepath.Glob("/proc/[0-9]*/status")
+	count := 0
+	for _, entry := range entries {
+		data, err := os.ReadFile(entry)
+		if err != nil {
+			continue
+		}
+		owned, threads := false, 0
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 2 {
+				continue
+			}
+			switch fields[0] {
+			case "Uid:":
+				owned = fields[1] == uid
+			case "Threads:":
+				threads, _ = strconv.Atoi(fields[1])
+			}
+		}
+		if owned {
+			count += threads
+		}
+	}
+	return count
+}
+
+var (
+	pidsCgroupOnce    sync.Once
+	pidsCgroupParents []string
+)
+
+// cgroupParents returns the folders of this process's own cgroup in every
+// hierarchy that may limit processes, cgroup v1 pids first, then cgroup v2
+func cgroupParents() []string {
+	pidsCgroupOnce.Do(func() {
+		own, err := os.ReadFile("/proc/self/cgroup")
+		if err != nil {
+			return
+		}
+		mounts, err := os.ReadFile("/proc/self/mountinfo")
+		if err != nil {
+			return
+		}
+		var v1, v2 []string
+		for _, line := range strings.Split(string(mounts), "\n") {
+			fields := strings.Fields(line)
+			sep := -1
+			for i, field := range fields {
+				if field == "-" {
+					sep = i
+					break
+				}
+			}
+			if sep < 5 || len(fields) < sep+4 {
+				continue
+			}
+			fsType, options := fields[sep+1], strings.Split(fields[sep+3], ",")
+			switch {
+			case fsType == "cgroup" && contains(options, "pids"):
+				if dir := cgroupDir(string(own), "pids", fields[3], fields[4]); dir != "" {
+					v1 = append(v1, dir)
+				}
+			case fsType == "cgroup2":
+				if dir := cgroupDir(string(own), "", fields[3], fields[4]); dir != "" {
+					v2 = append(v2, dir)
+				}
+			}
+		}
+		pidsCgroupParents = append(v1, v2...)
+	})
+	return pidsCgroupParents
+}
+
+// cgroupDir finds where the cgroup of this process for controller ("" for
+// cgroup v2) is in a hierarchy whose root folder root is mounted at point
+func cgroupDir(own, controller, root, point string) string {
+	for _, line := range strings.Split(own, "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if controller == "" && (parts[0] != "0" || parts[1] != "") {
+			continue
+		}
+		if controller != "" && !contains(strings.Split(parts[1], ","), controller) {
+			continue
+		}
+		rel, err := filepath.Rel(root, parts[2])
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			return ""
+		}
+		return filepath.Join(point, rel)
+	}
+	return ""
+}
+
+// newPidsCgroup creates a cgroup capped at sandboxProcesses for the sandbox
+// with id, or returns "" when this process may not create one
+func newPidsCgroup(id string) string {
+	for _, parent := range cgroupParents() {
+		dir := filepath.Join(parent, "quest-"+id)
+		if os.Mkdir(dir, 0755) != nil {
+			continue
+		}
+		if os.WriteFile(filepath.Join(dir, "pids.max"), []byte(strconv.Itoa(sandboxProcesses)), 0644) == nil {
+			return dir
+		}
+		os.Remove(dir)
+	}
+	return ""
+}
+
+// removePidsCgroup deletes the cgroup once the processes killed in it are gone
+func removePidsCgroup(dir string) {
+	if dir == "" {
+		return
+	}
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if err := os.Remove(dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+	}
+}
+
+// sandboxProcessIDs lists the processes of the sandbox: those in its cgroup
+// and those carrying its marker, which finds daemonized servers that left the
+// process group
+func sandboxProcessIDs(s *sandbox) []int {
+	marker := []byte("\x00" + sandboxMarker + "=" + s.id + "\x00")
+	self := os.Getpid()
+	seen := map[int]bool{}
+	var pids []int
+	add := func(pid int) {
+		if pid != self && !seen[pid] {
+			seen[pid] = true
+			pids = append(pids, pid)
+		}
+	}
+
+	if s.cgroup != "" {
+		if data, err := os.ReadFile(filepath.Join(s.cgroup, "cgroup.procs")); err == nil {
+			for _, field := range strings.Fields(string(data)) {
+				if pid, err := strconv.Atoi(field); err == nil {
+					add(pid)
+				}
+			}
+		}
+	}
+	entries, _ := filepath.Glob("/proc/[0-9]*/environ")
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(filepath.Base(filepath.Dir(entry)))
+		if err != nil {
+			continue
+		}
+		environ, err := os.ReadFile(entry)
+		if err != nil || !bytes.Contains(append(append([]byte{0}, environ...), 0), marker) {
+			continue
+		}
+		add(pid)
+	}
+	return pids
+}
+
+// killSandboxOrphans kills everything still running from the sandbox. It
+// repeats until nothing is left, the process limit keeps a fork bomb from
+// refilling faster than it is killed.
+func killSandboxOrphans(s *sandbox) {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		pids := sandboxProcessIDs(s)
+		if len(pids) == 0 {
+			return
+		}
+		for _, pid := range pids {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package quest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSandboxLimitsAndOrphans(t *testing.T) {
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("setsid is not installed")
+	}
+
+	sb, err := newSandbox(nil)
+	if err != nil {
+		t.Fatalf("newSandbox(nil) error = %v", err)
+	}
+	defer sb.Close()
+
+	cmd, _ := sb.Command("sh", "-c", "ulimit -t")
+	out, err := cmd.Output()
+	if err != nil || strings.TrimSpace(string(out)) != strconv.Itoa(sandboxCPUSeconds) {
+		t.Errorf("Expected a CPU limit of %d seconds, got %q (%v)", sandboxCPUSeconds, out, err)
+	}
+
+	// A process that escapes the process group is still found and killed
+	cmd, _ = sb.Command("sh", "-c", "setsid sleep 60 > /dev/null 2>&1 & echo $!")
+	out, err = cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatalf("Unexpected output %q", out)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	killSandboxOrphans(sb)
+	for ctx.Err() == nil {
+		// Signal 0 only checks for existence, zombies are reaped by init
+		if syscall.Kill(pid, 0) != nil || processIsZombie(pid) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Errorf("Expected orphaned process %d to be killed", pid)
+}
+
+func TestSandboxRunIgnoresStraysHoldingOutput(t *testing.T) {
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("setsid is not installed")
+	}
+
+	sb, err := newSandbox(nil)
+	if err != nil {
+		t.Fatalf("newSandbox(nil) error = %v", err)
+	}
+	defer sb.Close()
+
+	// The daemonized sleep keeps stdout open long after sh has exited
+	cmd, _ := sb.Command("sh", "-c", "setsid sleep 60 & echo done")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := sb.Run(ctx, cmd); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected Run to return once sh exited, took %v", elapsed)
+	}
+	if strings.TrimSpace(stdout.String()) != "done" {
+		t.Errorf("Expected the output before sh exited, got %q", stdout.String())
+	}
+	if running := len(sandboxProcessIDs(sb)); running != 0 {
+		t.Errorf("Expected the daemonized sleep to be killed, %d processes left", running)
+	}
+}
+
+func TestSandboxCapsProcesses(t *testing.T) {
+	if !processesCapped() {
+		t.Skip("no process limit can be applied here")
+	}
+	sb, err := newSandbox(nil)
+	if err != nil {
+		t.Fatalf("newSandbox(nil) error = %v", err)
+	}
+	defer sb.Close()
+
+	// Starting twice as many processes as allowed runs into the cap
+	script := fmt.Sprintf("i=0; while [ $i -lt %d ]; do sleep 60 & i=$((i+1)); done 2>/dev/null; exit 0", 2*sandboxProcesses)
+	cmd, _ := sb.Command("sh", "-c", script)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := runContext(ctx, cmd, nil); ctx.Err() != nil {
+		t.Fatalf("Expected the forking command to finish, got %v", err)
+	}
+	if running := len(sandboxProcessIDs(sb)); running == 0 || running > sandboxProcesses {
+		t.Errorf("Expected at most %d processes from the sandbox, found %d", sandboxProcesses, running)
+	}
+
+	killSandboxOrphans(sb)
+	if running := len(sandboxProcessIDs(sb)); running != 0 {
+		t.Errorf("Expected every process of the sandbox to be killed, %d left", running)
+	}
+}
+
+func processIsZombie(pid int) bool {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(data))
+	return len(fields) > 2 && fields[2] == "Z"
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package quest
+
+import "os/exec"
+
+// limitedCommand runs name directly, resource limits are only applied on Linux
+func limitedCommand(cgroup, name string, args []string) *exec.Cmd {
+	return exec.Command(name, args...)
+}
+
+// warnUnappliedLimits has nothing to report, no limits are applied here
+func warnUnappliedLimits() {}
+
+// newPidsCgroup has no cgroups to create here
+func newPidsCgroup(id string) string { return "" }
+
+func removePidsCgroup(dir string) {}
+
+// killSandboxOrphans has no way to find strays here, the process group kill
+// in runContext and startServer covers the common case
+func killSandboxOrphans(s *sandbox) {}
@@ -0,0 +1,54 @@
+package quest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSandboxIsolation(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	os.MkdirAll(filepath.Join("cmd", "app"), 0755)
+	os.WriteFile(filepath.Join("cmd", "app", "main.go"), []byte("package main\n"), 0644)
+	os.MkdirAll(QuestDir, 0755)
+	os.WriteFile(StateFilePath, []byte("{}"), 0644)
+
+	sb, err := newSandbox(nil)
+	if err != nil {
+		t.Fatalf("newSandbox(nil) error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(sb.Dir, "cmd", "app", "main.go")); err != nil {
+		t.Errorf("Expected workspace files in the sandbox: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(sb.Dir, StateFilePath)); !os.IsNotExist(err) {
+		t.Error("Expected .quest to be left out of the sandbox")
+	}
+
+	cmd, err := sb.Command("sh", "-c", "echo changed > cmd/app/main.go; echo \"$HOME\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Sandboxed command failed: %v", err)
+	}
+	if home := strings.TrimSpace(string(out)); !strings.HasPrefix(home, sb.root) {
+		t.Errorf("Expected HOME inside the sandbox, got %s", home)
+	}
+	if data, _ := os.ReadFile(filepath.Join("cmd", "app", "main.go")); string(data) != "package main\n" {
+		t.Error("Sandboxed command changed the real workspace")
+	}
+
+	sb.Close()
+	if _, err := os.Stat(sb.root); !os.IsNotExist(err) {
+		t.Error("Expected the sandbox to be removed on Close")
+	}
+}
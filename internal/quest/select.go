@@ -0,0 +1,159 @@
+package quest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// selectTasks resolves a task number or ID, or a chapter "N" or quest "N.M",
+// into indexes of the flattened task list
+func selectTasks(plan *types.Plan, task, chapter string) ([]int, error) {
+	tasks := FlattenTasks(plan)
+
+	if task != "" {
+		if num, err := strconv.Atoi(task); err == nil {
+			if num < 1 || num > len(tasks) {
+				return nil, fmt.Errorf("task %d is out of range (1-%d)", num, len(tasks))
+			}
+			return []int{num - 1}, nil
+		}
+		for i, t := range tasks {
+			if t.ID == task {
+				return []int{i}, nil
+			}
+		}
+		return nil, fmt.Errorf("no task with ID '%s'", task)
+	}
+
+	parts := strings.SplitN(chapter, ".", 2)
+	chapterNum, err := strconv.Atoi(parts[0])
+	if err != nil || chapterNum < 1 || chapterNum > len(plan.Chapters) {
+		return nil, fmt.Errorf("chapter '%s' is out of range (1-%d)", parts[0], len(plan.Chapters))
+	}
+	questNum := 0
+	if len(parts) == 2 {
+		questNum, err = strconv.Atoi(parts[1])
+		quests := plan.Chapters[chapterNum-1].Quests
+		if err != nil || questNum < 1 || questNum > len(quests) {
+			return nil, fmt.Errorf("quest '%s' is out of range (1-%d) in chapter %d", parts[1], len(quests), chapterNum)
+		}
+	}
+
+	var indexes []int
+	index := 0
+	for chIdx, ch := range plan.Chapters {
+		for qIdx, q := range ch.Quests {
+			for range q.Tasks {
+				if chIdx == chapterNum-1 && (questNum == 0 || qIdx == questNum-1) {
+					indexes = append(indexes, index)
+				}
+				index++
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("chapter %s has no tasks", chapter)
+	}
+	return indexes, nil
+}
+
+// runSelectedCheck checks the tasks picked with --task or --chapter without
+// moving the cursor. Passing tasks are still recorded as completed.
+func runSelectedCheck(cmd *cobra.Command, report *Report, state *types.State, plan *types.Plan, task, chapter string) error {
+	indexes, err := selectTasks(plan, task, chapter)
+	if err != nil {
+		format.ErrorWithTip("Invalid task selection", err, "Use a task number or ID with --task, or a chapter (2) or quest (2.1) with --chapter")
+		return exitWith(ExitInternal, err)
+	}
+
+	tasks := FlattenTasks(plan)
+	selected := make([]types.Task, len(indexes))
+	for n, i := range indexes {
+		selected[n] = tasks[i]
+	}
+	opts := checkOptions(cmd, state)
+	defer opts.Workspace.Close()
+	holdLockForCheck(cmd, selected, opts)
+	ctx, cancel := checkContext(cmd)
+	defer cancel()
+
+	passedTasks := 0
+	var passed []int
+	var events []Event
+
+	for _, i := range indexes {
+		current := i == state.CurrentTaskIndex
+		taskOpts := opts
+		if !current {
+			taskOpts.TaskStartedAt = time.Time{}
+			taskOpts.TaskStartCommit = ""
+		}
+
+		format.CheckHeader(i+1, tasks[i].Title)
+		started := time.Now()
+		passedCount, failedCount, results := checkTask(ctx, &tasks[i], taskOpts, current)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			format.Newline()
+			format.Warning("Check cancelled, nothing was saved")
+			return exitWith(ExitFail, errCancelled)
+		}
+
+		trackCheck(state, tasks[i].ID, failedCount == 0)
+		status := types.CheckFail
+		if failedCount == 0 {
+			status = types.CheckPass
+			passedTasks++
+			format.CheckSummaryPass(passedCount)
+			if !contains(state.CompletedTaskIDs, tasks[i].ID) {
+				state.CompletedTaskIDs = append(state.CompletedTaskIDs, tasks[i].ID)
+			}
+			state.RegressedTaskIDs = remove(state.RegressedTaskIDs, tasks[i].ID)
+			passed = append(passed, i)
+		} else {
+			format.CheckSummaryFail(passedCount, failedCount)
+		}
+
+		taskInfo := taskReport(i, tasks[i], state)
+		taskInfo.Check = taskCheck(passedCount, failedCount, results)
+		report.Tasks = append(report.Tasks, taskInfo)
+		events = append(events, checkEvent(SourceCheck, i, tasks[i], status, time.Since(started), results))
+
+		if current {
+			state.LastCheck = &types.CheckResult{
+				TaskID:    i,
+				Status:    status,
+				Timestamp: time.Now(),
+			}
+		}
+	}
+
+	if len(indexes) > 1 {
+		format.Info(fmt.Sprintf("%d of %d tasks passed", passedTasks, len(indexes)))
+	}
+
+	if err := opts.Cache.Save(); err != nil {
+		format.Warning(fmt.Sprintf("Could not save the check cache: %v", err))
+	}
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		format.ErrorWithTip("Failed to save quest data", err, "Check folder permissions")
+		return exitWith(ExitInternal, err)
+	}
+	for _, i := range passed {
+		snapshotTask(state, tasks[i], SnapshotPass)
+	}
+	for _, event := range events {
+		logEvent(event)
+	}
+	if passedTasks < len(indexes) {
+		return exitWith(ExitFail, errChecksFailed)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package quest
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestSelectTasks(t *testing.T) {
+	plan := &types.Plan{
+		Chapters: []types.Chapter{
+			{Title: "Basics", Quests: []types.Quest{
+				{Title: "Setup", Tasks: []types.Task{{ID: "go-mod"}, {ID: "main"}}},
+				{Title: "Build", Tasks: []types.Task{{ID: "build"}}},
+			}},
+			{Title: "Routes", Quests: []types.Quest{
+				{Title: "Handlers", Tasks: []types.Task{{ID: "handlers"}, {ID: "tests"}}},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		task    string
+		chapter string
+		want    []int
+		wantErr bool
+	}{
+		{name: "task number", task: "3", want: []int{2}},
+		{name: "task ID", task: "handlers", want: []int{3}},
+		{name: "task out of range", task: "6", wantErr: true},
+		{name: "unknown task ID", task: "missing", wantErr: true},
+		{name: "whole chapter", chapter: "1", want: []int{0, 1, 2}},
+		{name: "quest in chapter", chapter: "1.2", want: []int{2}},
+		{name: "second chapter", chapter: "2", want: []int{3, 4}},
+		{name: "chapter out of range", chapter: "3", wantErr: true},
+		{name: "quest out of range", chapter: "2.2", wantErr: true},
+		{name: "not a number", chapter: "routes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectTasks(plan, tt.task, tt.chapter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectTasks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSelectedTaskKeepsCursor(t *testing.T) {
+	writeRegressionQuest(t)
+
+	state, _ := LoadState()
+	state.CurrentTaskIndex = 0
+	state.CompletedTaskIDs = nil
+	state.ExplainCount = 2
+	plan, _ := LoadPlan()
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile("handlers.go", []byte("package main\n"), 0644)
+	RunCheck(testCommand(t, "check", "--task", "handlers"), nil)
+
+	state, err := LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.CurrentTaskIndex != 0 || state.ExplainCount != 2 {
+		t.Errorf("Expected cursor and explain count unchanged, got task %d, explains %d", state.CurrentTaskIndex, state.ExplainCount)
+	}
+	if !contains(state.CompletedTaskIDs, "handlers") {
+		t.Errorf("Expected 'handlers' to be recorded as completed, got %v", state.CompletedTaskIDs)
+	}
+
+	// Chapter 1 has one passing and one failing task
+	RunCheck(testCommand(t, "check", "--chapter", "1"), nil)
+	state, _ = LoadState()
+	if !contains(state.CompletedTaskIDs, "go-mod") || contains(state.CompletedTaskIDs, "main") {
+		t.Errorf("Expected only 'go-mod' completed from chapter 1, got %v", state.CompletedTaskIDs)
+	}
+	if state.CurrentTaskIndex != 0 {
+		t.Errorf("Expected cursor unchanged, got %d", state.CurrentTaskIndex)
+	}
+}
@@ -0,0 +1,169 @@
+package quest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultServerCommand starts the learner's server when a rule sets no command
+const DefaultServerCommand = "go run ."
+
+// DefaultBaseURL is where the learner's server is expected to listen
+const DefaultBaseURL = "http://localhost:8080"
+
+// serverStartupTimeout covers compiling the server with go run
+const serverStartupTimeout = 60 * time.Second
+
+// lockedBuffer collects process output written from another goroutine
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// serverSlot lets only one rule run the learner's server at a time, since
+// they all expect it on the same port. A rule holds it by sending to it.
+var serverSlot = make(chan struct{}, 1)
+
+// startServer runs command in the background from a sandbox and waits until
+// baseURL accepts TCP connections. The returned stop function kills the server and anything
+// it spawned; cancelling ctx while starting does the same.
+func startServer(ctx context.Context, command, baseURL string, workspace *workspaceCopy) (func(), error) {
+	address, err := dialAddress(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Waiting for another rule's server gives up with the check
+	select {
+	case serverSlot <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	started := false
+	defer func() {
+		if !started {
+			<-serverSlot
+		}
+	}()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if conn, err := net.DialTimeout("tcp", address, 200*time.Millisecond); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("something is already listening on %s, stop it so quest can start your server", address)
+	}
+
+	if command == "" {
+		command = DefaultServerCommand
+	}
+	sb, err := newSandbox(workspace)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(command)
+	cmd, err := sb.Command(fields[0], fields[1:]...)
+	if err != nil {
+		sb.Close()
+		return nil, fmt.Errorf("failed to start '%s': %w", command, err)
+	}
+	output := &lockedBuffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	setProcessGroup(cmd)
+
+	// Output goes through pipes of our own so waiting for the server ends
+	// with it, not with the last daemonized child holding its output
+	piped, err := redirectOutput(cmd)
+	if err != nil {
+		sb.Close()
+		return nil, fmt.Errorf("failed to start '%s': %w", command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		piped.abort()
+		sb.Close()
+		return nil, fmt.Errorf("failed to start '%s': %w", command, err)
+	}
+	piped.started()
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	// kill stops the server and everything it left in the sandbox, then
+	// deletes the sandbox. It runs on every way out but a started server.
+	kill := func() {
+		killProcessGroup(cmd)
+		killSandboxOrphans(sb)
+		<-exited
+		piped.wait(strayOutputGrace)
+		sb.Close()
+	}
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			kill()
+			<-serverSlot
+		})
+	}
+
+	deadline := time.Now().Add(serverStartupTimeout)
+	for {
+		select {
+		case <-exited:
+			kill()
+			return nil, fmt.Errorf("'%s' exited before listening on %s: %s", command, address, firstLines(output.String(), 5))
+		case <-ctx.Done():
+			kill()
+			return nil, ctx.Err()
+		default:
+		}
+
+		if conn, err := net.DialTimeout("tcp", address, 200*time.Millisecond); err == nil {
+			conn.Close()
+			started = true
+			return stop, nil
+		}
+
+		if time.Now().After(deadline) {
+			kill()
+			return nil, fmt.Errorf("'%s' did not start listening on %s within %s", command, address, serverStartupTimeout)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// dialAddress turns a base URL into host:port
+func dialAddress(baseURL string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid base URL '%s'", baseURL)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	}
+	return net.JoinHostPort(u.Hostname(), "80"), nil
+}
@@ -0,0 +1,62 @@
+package quest
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartServerWaitHonoursContext(t *testing.T) {
+	// Another rule holds the server until the test ends
+	serverSlot <- struct{}{}
+	defer func() { <-serverSlot }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	stop, err := startServer(ctx, "", DefaultBaseURL, nil)
+	if stop != nil {
+		stop()
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected waiting for the server to give up with the context, got %v", err)
+	}
+	if time.Since(started) > 5*time.Second {
+		t.Errorf("Expected startServer to return soon after the timeout, took %s", time.Since(started))
+	}
+}
+
+func TestStartServerCleansUpWhenServerExits(t *testing.T) {
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("setsid is not installed")
+	}
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	// The server leaves a daemonized child holding its output and quits
+	started := time.Now()
+	stop, err := startServer(context.Background(), "sh -c 'setsid sleep 60 & echo bye; exit 1'", "http://127.0.0.1:18971", nil)
+	if stop != nil {
+		stop()
+	}
+	if err == nil || !strings.Contains(err.Error(), "exited before listening") || !strings.Contains(err.Error(), "bye") {
+		t.Errorf("Expected an error with the server's output, got %v", err)
+	}
+	if time.Since(started) > 10*time.Second {
+		t.Errorf("Expected startServer to return once the server exited, took %s", time.Since(started))
+	}
+
+	if left, _ := filepath.Glob(filepath.Join(tmp, "quest-sandbox-*")); len(left) != 0 {
+		t.Errorf("Expected the sandbox to be deleted, found %v", left)
+	}
+	select {
+	case serverSlot <- struct{}{}:
+		<-serverSlot
+	default:
+		t.Error("Expected the server slot to be released")
+	}
+}
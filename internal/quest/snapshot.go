@@ -0,0 +1,476 @@
+package quest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/prompt"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// When a snapshot was taken
+const (
+	SnapshotStart   = "start"   // quest next showed the task
+	SnapshotPass    = "pass"    // a check of the task passed
+	SnapshotAI      = "ai"      // before explain or check --annotate edited files
+	SnapshotRestore = "restore" // before quest restore or quest reset overwrote files
+)
+
+var snapshotKinds = []string{SnapshotStart, SnapshotPass, SnapshotAI, SnapshotRestore}
+
+// maxSnapshots is how many snapshots are kept per task, besides the first
+// start snapshot which is never dropped
+const maxSnapshots = 20
+
+// maxSnapshotFileSize skips large files, such as binaries, when the whole
+// module is snapshotted
+const maxSnapshotFileSize = 1 << 20
+
+// Snapshot records the content of the task's files at one moment. The
+// contents are stored once in the objects folder, keyed by their hash.
+type Snapshot struct {
+	Kind    string            `json:"kind"`
+	TakenAt time.Time         `json:"takenAt"`
+	Files   map[string]string `json:"files"` // path -> content hash
+}
+
+func snapshotIndexPath() string {
+	return filepath.Join(SnapshotsFolderPath, "index.json")
+}
+
+func objectPath(hash string) string {
+	return filepath.Join(SnapshotsFolderPath, "objects", hash[:2], hash[2:])
+}
+
+// loadSnapshots returns the snapshots of every task by task ID, oldest first
+func loadSnapshots() (map[string][]Snapshot, error) {
+	snapshots := map[string][]Snapshot{}
+	data, err := os.ReadFile(snapshotIndexPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return snapshots, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, fmt.Errorf("%s: %w", snapshotIndexPath(), err)
+	}
+	return snapshots, nil
+}
+
+func saveSnapshots(snapshots map[string][]Snapshot) error {
+	data, err := json.MarshalIndent(snapshots, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(snapshotIndexPath(), data, 0644)
+}
+
+// storeObject saves content under its hash unless it is already there
+func storeObject(content []byte) (string, error) {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	path := objectPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return hash, writeFileAtomic(path, content, 0644)
+}
+
+func readObject(hash string) ([]byte, error) {
+	if len(hash) < 3 {
+		return nil, fmt.Errorf("invalid snapshot object '%s'", hash)
+	}
+	return os.ReadFile(objectPath(hash))
+}
+
+// snapshotFiles returns the files a snapshot of task covers that exist: the
+// task's artifacts and starter files, or every file of the module when the
+// quest was begun with --snapshot-module
+func snapshotFiles(state *types.State, task types.Task) ([]string, error) {
+	if !state.SnapshotModule {
+		seen := map[string]bool{}
+		var files []string
+		for _, path := range existingFiles(append(append([]string(nil), task.Artifacts...), task.Files...)) {
+			path = filepath.ToSlash(filepath.Clean(path))
+			if !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
+	var files []string
+	err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != "." && (info.Name() == QuestFolderName || info.Name() == ".git" || info.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() && info.Size() <= maxSnapshotFileSize {
+			files = append(files, filepath.ToSlash(path))
+		}
+		return nil
+	})
+	return files, err
+}
+
+// takeSnapshot stores the current content of the task's files. Nothing is
+// added when it matches the task's last snapshot of the same kind.
+func takeSnapshot(state *types.State, task types.Task, kind string) error {
+	files, err := snapshotFiles(state, task)
+	if err != nil {
+		return err
+	}
+	snapshot := Snapshot{Kind: kind, TakenAt: time.Now(), Files: map[string]string{}}
+	for _, path := range files {
+		content, err := os.ReadFile(filepath.FromSlash(path))
+		if err != nil {
+			return err
+		}
+		hash, err := storeObject(content)
+		if err != nil {
+			return err
+		}
+		snapshot.Files[path] = hash
+	}
+
+	snapshots, err := loadSnapshots()
+	if err != nil {
+		return err
+	}
+	taken := snapshots[task.ID]
+	if last := latestSnapshot(taken, kind); last != nil && sameFiles(last.Files, snapshot.Files) {
+		return nil
+	}
+	taken = append(taken, snapshot)
+	if len(taken) > maxSnapshots {
+		// Keep the first start snapshot, it is what reset and restore go back to
+		keep := taken[:0:0]
+		if taken[0].Kind == SnapshotStart {
+			keep = append(keep, taken[0])
+		}
+		taken = append(keep, taken[len(taken)-maxSnapshots+len(keep):]...)
+	}
+	snapshots[task.ID] = taken
+	return saveSnapshots(snapshots)
+}
+
+// snapshotTask takes a snapshot and only warns when it fails, losing a
+// snapshot must never fail the command that triggered it
+func snapshotTask(state *types.State, task types.Task, kind string) {
+	if err := takeSnapshot(state, task, kind); err != nil {
+		format.Warning(fmt.Sprintf("Could not snapshot the files of '%s': %v", task.Title, err))
+	}
+}
+
+func sameFiles(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, hash := range a {
+		if b[path] != hash {
+			return false
+		}
+	}
+	return true
+}
+
+// latestSnapshot returns the most recent snapshot of kind, or of any kind
+// when kind is empty
+func latestSnapshot(snapshots []Snapshot, kind string) *Snapshot {
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if kind == "" || snapshots[i].Kind == kind {
+			return &snapshots[i]
+		}
+	}
+	return nil
+}
+
+// firstSnapshot returns the oldest snapshot of kind
+func firstSnapshot(snapshots []Snapshot, kind string) *Snapshot {
+	for i := range snapshots {
+		if snapshots[i].Kind == kind {
+			return &snapshots[i]
+		}
+	}
+	return nil
+}
+
+// snapshotFor finds the snapshot quest diff and quest restore work from:
+// the first start snapshot, since that is where the task began, or the
+// latest snapshot of any other kind
+func snapshotFor(taskID, kind string) (*Snapshot, error) {
+	valid := false
+	for _, k := range snapshotKinds {
+		valid = valid || k == kind
+	}
+	if !valid {
+		return nil, fmt.Errorf("unknown snapshot '%s', use %s", kind, strings.Join(snapshotKinds, ", "))
+	}
+	snapshots, err := loadSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	if kind == SnapshotStart {
+		if snapshot := firstSnapshot(snapshots[taskID], kind); snapshot != nil {
+			return snapshot, nil
+		}
+	} else if snapshot := latestSnapshot(snapshots[taskID], kind); snapshot != nil {
+		return snapshot, nil
+	}
+	return nil, fmt.Errorf("no '%s' snapshot of this task: %w", kind, fs.ErrNotExist)
+}
+
+// FileChange is a file that differs between a snapshot and the workspace
+type FileChange struct {
+	Path    string
+	Before  []byte
+	After   []byte
+	Existed bool // the file was in the snapshot
+	Exists  bool // the file is in the workspace
+}
+
+// snapshotChanges compares a snapshot of task with the files in the
+// workspace now, listing each changed file once in path order
+func snapshotChanges(state *types.State, task types.Task, snapshot *Snapshot) ([]FileChange, error) {
+	paths := map[string]bool{}
+	for path := range snapshot.Files {
+		paths[path] = true
+	}
+	current, err := snapshotFiles(state, task)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range current {
+		paths[path] = true
+	}
+
+	var changes []FileChange
+	for path := range paths {
+		change := FileChange{Path: path}
+		if hash, ok := snapshot.Files[path]; ok {
+			change.Existed = true
+			if change.Before, err = readObject(hash); err != nil {
+				return nil, err
+			}
+		}
+		after, err := os.ReadFile(filepath.FromSlash(path))
+		if err == nil {
+			change.Exists = true
+			change.After = after
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if change.Existed == change.Exists && bytes.Equal(change.Before, change.After) {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// snapshotTaskFlag resolves --task to a task, the current one by default
+func snapshotTaskFlag(cmd *cobra.Command, plan *types.Plan, state *types.State) (int, types.Task, error) {
+	tasks := FlattenTasks(plan)
+	index := state.CurrentTaskIndex
+	if value, _ := cmd.Flags().GetString("task"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > len(tasks) {
+			return 0, types.Task{}, fmt.Errorf("invalid task number '%s', choose 1-%d", value, len(tasks))
+		}
+		index = n - 1
+	}
+	if index >= len(tasks) {
+		return 0, types.Task{}, fmt.Errorf("the quest is finished, choose a task with --task")
+	}
+	return index, tasks[index], nil
+}
+
+func RunDiff(cmd *cobra.Command, args []string) error {
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
+		return commandError(err)
+	}
+	index, task, err := snapshotTaskFlag(cmd, plan, state)
+	if err != nil {
+		format.Error("Invalid task", err)
+		return exitWith(ExitInternal, err)
+	}
+	kind, _ := cmd.Flags().GetString("at")
+	snapshot, err := snapshotFor(task.ID, kind)
+	if err != nil {
+		format.ErrorWithTip(fmt.Sprintf("No snapshot of task %d to compare with", index+1), err, "Snapshots are taken by 'quest next', passing checks and AI hints")
+		return commandError(err)
+	}
+	changes, err := snapshotChanges(state, task, snapshot)
+	if err != nil {
+		format.Error("Failed to compare with the snapshot", err)
+		return exitWith(ExitInternal, err)
+	}
+
+	since := fmt.Sprintf("the '%s' snapshot of task %d from %s", kind, index+1, snapshot.TakenAt.Local().Format("2006-01-02 15:04"))
+	if len(changes) == 0 {
+		format.Success("No changes since " + since)
+		return nil
+	}
+
+	format.Header(fmt.Sprintf("Changes since task %d: %s", index+1, task.Title))
+	for _, change := range changes {
+		before, after := "a/"+change.Path, "b/"+change.Path
+		if !change.Existed {
+			before = "/dev/null"
+		}
+		if !change.Exists {
+			after = "/dev/null"
+		}
+		printDiff(UnifiedDiff(string(change.Before), string(change.After), before, after))
+	}
+	format.Dim(fmt.Sprintf("%d file(s) changed since %s", len(changes), since))
+	format.CommandHint("Roll back with", fmt.Sprintf("quest restore --task %d --at %s", index+1, kind))
+	return nil
+}
+
+// printDiff colors a unified diff line by line
+func printDiff(diff string) {
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		color := ""
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			color = format.ColorBold
+		case strings.HasPrefix(line, "@@"):
+			color = format.ColorPink
+		case strings.HasPrefix(line, "+"):
+			color = format.ColorGreen
+		case strings.HasPrefix(line, "-"):
+			color = format.ColorRed
+		}
+		format.Line(color + line + format.ColorReset)
+	}
+	format.Newline()
+}
+
+func RunRestore(cmd *cobra.Command, args []string) error {
+	unlock, err := lockForUpdate()
+	if err != nil {
+		return commandError(err)
+	}
+	defer unlock()
+
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
+		return commandError(err)
+	}
+	index, task, err := snapshotTaskFlag(cmd, plan, state)
+	if err != nil {
+		format.Error("Invalid task", err)
+		return exitWith(ExitInternal, err)
+	}
+	kind, _ := cmd.Flags().GetString("at")
+	snapshot, err := snapshotFor(task.ID, kind)
+	if err != nil {
+		format.ErrorWithTip(fmt.Sprintf("No snapshot of task %d to restore", index+1), err, fmt.Sprintf("See what is there with 'quest diff --task %d'", index+1))
+		return commandError(err)
+	}
+	changes, err := snapshotChanges(state, task, snapshot)
+	if err != nil {
+		format.Error("Failed to compare with the snapshot", err)
+		return exitWith(ExitInternal, err)
+	}
+
+	var overwrite []FileChange
+	for _, change := range changes {
+		// Files the snapshot doesn't know are left alone
+		if change.Existed {
+			overwrite = append(overwrite, change)
+		}
+	}
+	if len(overwrite) == 0 {
+		format.Success(fmt.Sprintf("Task %d already matches its '%s' snapshot", index+1, kind))
+		return nil
+	}
+
+	var lines []string
+	for _, change := range overwrite {
+		action := "overwrite"
+		if !change.Exists {
+			action = "recreate "
+		}
+		lines = append(lines, fmt.Sprintf("%s%s%s %s", format.ColorDim, action, format.ColorReset, change.Path))
+	}
+	title := fmt.Sprintf("Restore task %d to '%s' (%s)", index+1, kind, snapshot.TakenAt.Local().Format("2006-01-02 15:04"))
+	if !confirmChanges(cmd, title, lines, "Overwrite these files? (y/N)") {
+		return exitWith(ExitFail, errCancelled)
+	}
+
+	if err := takeSnapshot(state, task, SnapshotRestore); err != nil {
+		format.ErrorWithTip("Could not snapshot your files before restoring", err, "Nothing was changed")
+		return exitWith(ExitInternal, err)
+	}
+	if err := writeChanges(overwrite); err != nil {
+		format.ErrorWithTip("Failed to restore files", err, fmt.Sprintf("Undo with 'quest restore --task %d --at %s'", index+1, SnapshotRestore))
+		return exitWith(ExitInternal, err)
+	}
+
+	format.Success(fmt.Sprintf("Restored %d file(s) of task %d", len(overwrite), index+1))
+	format.CommandHint("Changed your mind? Run", fmt.Sprintf("quest restore --task %d --at %s", index+1, SnapshotRestore))
+	return nil
+}
+
+// writeChanges puts the snapshot content of each change back in place
+func writeChanges(changes []FileChange) error {
+	for _, change := range changes {
+		path := filepath.FromSlash(change.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, change.Before, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// confirmChanges lists what a command is about to change and asks question
+// before going on, unless --yes was given
+func confirmChanges(cmd *cobra.Command, title string, lines []string, question string) bool {
+	format.Box(title, lines)
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return true
+	}
+	if !prompt.Interactive() {
+		format.ErrorWithTip(fmt.Sprintf("'quest %s' needs confirmation", cmd.Name()), nil, fmt.Sprintf("Run 'quest %s --yes' to skip the prompt", cmd.Name()))
+		return false
+	}
+	confirmed, err := prompt.Confirm(question)
+	if err != nil || !confirmed {
+		format.Warning("Nothing was changed")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package quest
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestTakeSnapshot(t *testing.T) {
+	chdirTemp(t)
+	state := &types.State{}
+	task := types.Task{ID: "handlers", Artifacts: []string{"handlers.go", "missing.go"}, Files: []string{"handlers.go"}}
+	os.WriteFile("handlers.go", []byte("// TODO: implement\n"), 0644)
+
+	for i := 0; i < 2; i++ {
+		if err := takeSnapshot(state, task, SnapshotStart); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile("handlers.go", []byte("package main\n"), 0644)
+	if err := takeSnapshot(state, task, SnapshotPass); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots, err := loadSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	taken := snapshots["handlers"]
+	if len(taken) != 2 || taken[0].Kind != SnapshotStart || taken[1].Kind != SnapshotPass {
+		t.Fatalf("Expected an unchanged snapshot to be skipped, got %+v", taken)
+	}
+	if len(taken[0].Files) != 1 {
+		t.Errorf("Expected only existing files, once each, got %v", taken[0].Files)
+	}
+	content, err := readObject(taken[0].Files["handlers.go"])
+	if err != nil || string(content) != "// TODO: implement\n" {
+		t.Errorf("Expected the stub to be stored, got %q (%v)", content, err)
+	}
+}
+
+func TestTakeSnapshotKeepsStart(t *testing.T) {
+	chdirTemp(t)
+	state := &types.State{}
+	task := types.Task{ID: "handlers", Artifacts: []string{"handlers.go"}}
+
+	for i := 0; i <= maxSnapshots+5; i++ {
+		os.WriteFile("handlers.go", []byte(fmt.Sprintf("// version %d\n", i)), 0644)
+		kind := SnapshotPass
+		if i == 0 {
+			kind = SnapshotStart
+		}
+		if err := takeSnapshot(state, task, kind); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots, _ := loadSnapshots()
+	taken := snapshots["handlers"]
+	if len(taken) != maxSnapshots || taken[0].Kind != SnapshotStart {
+		t.Errorf("Expected %d snapshots starting with the start one, got %d starting with %s", maxSnapshots, len(taken), taken[0].Kind)
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	chdirTemp(t)
+	task := types.Task{ID: "handlers", Artifacts: []string{"handlers.go"}}
+	plan := &types.Plan{Version: Version, NumberOfTasks: 1,
+		Chapters: []types.Chapter{{Quests: []types.Quest{{Tasks: []types.Task{task}}}}}}
+	state := &types.State{Version: Version, CompletedTaskIDs: []string{}}
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("handlers.go", []byte("// TODO: implement\n"), 0644)
+	takeSnapshot(state, task, SnapshotStart)
+	os.WriteFile("handlers.go", []byte("package main // wrecked\n"), 0644)
+
+	if err := RunRestore(testCommand(t, "restore", "--yes"), nil); ExitCode(err) != ExitNotInitialized {
+		t.Errorf("Expected restoring a snapshot that wasn't taken to fail, got %v", err)
+	}
+	if err := RunRestore(testCommand(t, "restore", "--task", "1", "--at", SnapshotStart, "--yes"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile("handlers.go"); string(data) != "// TODO: implement\n" {
+		t.Errorf("Expected the start of the task back, got %q", data)
+	}
+
+	if err := RunRestore(testCommand(t, "restore", "--task", "1", "--at", SnapshotRestore, "--yes"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile("handlers.go"); string(data) != "package main // wrecked\n" {
+		t.Errorf("Expected restore to be undone, got %q", data)
+	}
+}
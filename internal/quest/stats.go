@@ -0,0 +1,265 @@
+package quest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// slowestCount is how many tasks quest stats lists as the slowest
+const slowestCount = 3
+
+// taskStats returns the stats of a task, starting them if needed
+func taskStats(state *types.State, taskID string) *types.TaskStats {
+	if state.TaskStats == nil {
+		state.TaskStats = map[string]*types.TaskStats{}
+	}
+	stats := state.TaskStats[taskID]
+	if stats == nil {
+		stats = &types.TaskStats{}
+		state.TaskStats[taskID] = stats
+	}
+	return stats
+}
+
+// trackShown starts the clock of a task the first time it becomes current
+func trackShown(state *types.State, taskID string) {
+	stats := taskStats(state, taskID)
+	if stats.ShownAt.IsZero() {
+		stats.ShownAt = time.Now()
+	}
+}
+
+// trackCheck counts a check of a task and remembers its first failure and
+// first pass
+func trackCheck(state *types.State, taskID string, passed bool) {
+	stats := taskStats(state, taskID)
+	stats.Attempts++
+	now := time.Now()
+	if passed && stats.FirstPassAt.IsZero() {
+		stats.FirstPassAt = now
+	}
+	if !passed && stats.FirstFailAt.IsZero() {
+		stats.FirstFailAt = now
+	}
+}
+
+// trackHint counts a request for hints on a task
+func trackHint(state *types.State, taskID string) {
+	taskStats(state, taskID).Hints++
+}
+
+// StatsReport is what quest stats prints: where the time went and how long
+// the rest of the quest should take at the current pace
+type StatsReport struct {
+	Tasks    []TaskTiming    `json:"tasks"`
+	Chapters []ChapterTiming `json:"chapters"`
+	Slowest  []TaskTiming    `json:"slowest,omitempty"`
+
+	TotalMs   int64 `json:"totalMs"`
+	AverageMs int64 `json:"averageMs,omitempty"` // per finished task
+	Remaining int   `json:"remaining"`           // tasks not completed yet
+	ETAMs     int64 `json:"etaMs,omitempty"`     // Remaining at the average pace
+}
+
+// TaskTiming is the time and effort one task took. A task's time runs from
+// when it was shown until its first passing check, or until now while it is
+// the current task.
+type TaskTiming struct {
+	Number      int        `json:"number"`
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Chapter     int        `json:"chapter"`
+	Status      string     `json:"status"`
+	DurationMs  int64      `json:"durationMs"`
+	InProgress  bool       `json:"inProgress,omitempty"`
+	Attempts    int        `json:"attempts"`
+	Hints       int        `json:"hints"`
+	ShownAt     *time.Time `json:"shownAt,omitempty"`
+	FirstFailAt *time.Time `json:"firstFailAt,omitempty"`
+	FirstPassAt *time.Time `json:"firstPassAt,omitempty"`
+}
+
+// ChapterTiming adds up the tasks of a chapter
+type ChapterTiming struct {
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	DurationMs int64  `json:"durationMs"`
+	Completed  int    `json:"completed"`
+	Total      int    `json:"total"`
+	Attempts   int    `json:"attempts"`
+	Hints      int    `json:"hints"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// buildStats computes the stats of every task as of now
+func buildStats(plan *types.Plan, state *types.State, now time.Time) *StatsReport {
+	report := &StatsReport{Tasks: []TaskTiming{}, Chapters: []ChapterTiming{}}
+	var finished []TaskTiming
+	index := 0
+
+	for chIdx, chapter := range plan.Chapters {
+		chapterTiming := ChapterTiming{Number: chIdx + 1, Title: chapter.Title}
+		for _, quest := range chapter.Quests {
+			for _, task := range quest.Tasks {
+				timing := TaskTiming{
+					Number:  index + 1,
+					ID:      task.ID,
+					Title:   task.Title,
+					Chapter: chIdx + 1,
+					Status:  taskReport(index, task, state).Status,
+				}
+				if stats := state.TaskStats[task.ID]; stats != nil {
+					timing.Attempts = stats.Attempts
+					timing.Hints = stats.Hints
+					timing.ShownAt = optionalTime(stats.ShownAt)
+					timing.FirstFailAt = optionalTime(stats.FirstFailAt)
+					timing.FirstPassAt = optionalTime(stats.FirstPassAt)
+
+					switch {
+					case stats.ShownAt.IsZero():
+					case !stats.FirstPassAt.IsZero():
+						if stats.FirstPassAt.After(stats.ShownAt) {
+							timing.DurationMs = stats.FirstPassAt.Sub(stats.ShownAt).Milliseconds()
+						}
+						finished = append(finished, timing)
+					case state.QuestStarted && index == state.CurrentTaskIndex:
+						timing.DurationMs = now.Sub(stats.ShownAt).Milliseconds()
+						timing.InProgress = true
+					}
+				}
+
+				if timing.Status == "completed" {
+					chapterTiming.Completed++
+				} else {
+					report.Remaining++
+				}
+				chapterTiming.Total++
+				chapterTiming.DurationMs += timing.DurationMs
+				chapterTiming.Attempts += timing.Attempts
+				chapterTiming.Hints += timing.Hints
+				report.TotalMs += timing.DurationMs
+				report.Tasks = append(report.Tasks, timing)
+				index++
+			}
+		}
+		report.Chapters = append(report.Chapters, chapterTiming)
+	}
+
+	if len(finished) > 0 {
+		var sum int64
+		for _, timing := range finished {
+			sum += timing.DurationMs
+		}
+		report.AverageMs = sum / int64(len(finished))
+		report.ETAMs = report.AverageMs * int64(report.Remaining)
+
+		sort.SliceStable(finished, func(i, j int) bool { return finished[i].DurationMs > finished[j].DurationMs })
+		if len(finished) > slowestCount {
+			finished = finished[:slowestCount]
+		}
+		report.Slowest = finished
+	}
+	return report
+}
+
+// humanDuration rounds ms to what matters at its size: 45s, 12m, 2h05m, 3d04h
+func humanDuration(ms int64) string {
+	d := time.Duration(ms) * time.Millisecond
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+}
+
+// truncate shortens s to n runes for a table column
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+func RunStats(cmd *cobra.Command, args []string) (err error) {
+	report := newReport(cmd, "stats")
+	defer func() { err = report.finish(err) }()
+
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
+		return commandError(err)
+	}
+
+	stats := buildStats(plan, state, time.Now())
+	report.Stats = stats
+	report.Progress = progressReport(plan, state)
+	printStats(stats)
+	return nil
+}
+
+func printStats(stats *StatsReport) {
+	format.Header("Quest Stats")
+
+	for _, chapter := range stats.Chapters {
+		format.SectionHeader(fmt.Sprintf("Chapter %d: %s", chapter.Number, chapter.Title))
+		format.Line(fmt.Sprintf("%s       %-36s %8s %7s %6s%s", format.ColorDim, "Task", "Time", "Checks", "Hints", format.ColorReset))
+		for _, task := range stats.Tasks {
+			if task.Chapter != chapter.Number {
+				continue
+			}
+			mark := " "
+			switch task.Status {
+			case "completed":
+				mark = format.ColorGreen + "✔" + format.ColorReset
+			case "regressed":
+				mark = format.ColorYellow + "⚠" + format.ColorReset
+			case "current":
+				mark = format.ColorPink + "▸" + format.ColorReset
+			}
+			duration := humanDuration(task.DurationMs)
+			if task.InProgress {
+				duration += "…"
+			}
+			format.Line(fmt.Sprintf("%s %3d  %-36s %8s %7d %6d", mark, task.Number, truncate(task.Title, 36), duration, task.Attempts, task.Hints))
+		}
+		format.Dim(fmt.Sprintf("%d of %d tasks · %s · %d checks · %d hints",
+			chapter.Completed, chapter.Total, humanDuration(chapter.DurationMs), chapter.Attempts, chapter.Hints))
+	}
+
+	if len(stats.Slowest) > 0 {
+		format.SectionHeader("Slowest tasks")
+		for _, task := range stats.Slowest {
+			format.Line(fmt.Sprintf("%8s  Task %d: %s", humanDuration(task.DurationMs), task.Number, task.Title))
+		}
+	}
+
+	format.Newline()
+	format.Line(fmt.Sprintf("%sTotal time:%s %s", format.ColorPink, format.ColorReset, humanDuration(stats.TotalMs)))
+	if stats.AverageMs > 0 {
+		format.Line(fmt.Sprintf("%sPace:%s %s per task", format.ColorPink, format.ColorReset, humanDuration(stats.AverageMs)))
+		if stats.Remaining > 0 {
+			format.Line(fmt.Sprintf("%sETA:%s about %s for the %d remaining task(s)", format.ColorPink, format.ColorReset, humanDuration(stats.ETAMs), stats.Remaining))
+		}
+	} else if stats.Remaining > 0 {
+		format.Dim("Pass a task to get an estimate for the rest of the quest")
+	}
+	format.Newline()
+}
@@ -0,0 +1,99 @@
+package quest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func statsPlan() *types.Plan {
+	task := func(id string) types.Task { return types.Task{ID: id, Title: id} }
+	return &types.Plan{
+		NumberOfTasks: 4,
+		Chapters: []types.Chapter{
+			{Title: "Basics", Quests: []types.Quest{{Tasks: []types.Task{task("a"), task("b")}}}},
+			{Title: "More", Quests: []types.Quest{{Tasks: []types.Task{task("c"), task("d")}}}},
+		},
+	}
+}
+
+func TestBuildStats(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	state := &types.State{
+		QuestStarted:     true,
+		CurrentTaskIndex: 2,
+		CompletedTaskIDs: []string{"a", "b"},
+		TaskStats: map[string]*types.TaskStats{
+			"a": {ShownAt: start, FirstPassAt: start.Add(10 * time.Minute), Attempts: 2},
+			"b": {ShownAt: start.Add(10 * time.Minute), FirstFailAt: start.Add(15 * time.Minute), FirstPassAt: start.Add(40 * time.Minute), Attempts: 4, Hints: 2},
+			"c": {ShownAt: start.Add(40 * time.Minute), Attempts: 1},
+		},
+	}
+
+	stats := buildStats(statsPlan(), state, start.Add(45*time.Minute))
+	if len(stats.Tasks) != 4 || len(stats.Chapters) != 2 {
+		t.Fatalf("Expected 4 tasks in 2 chapters, got %+v", stats)
+	}
+	if got := stats.Tasks[1].DurationMs; got != (30 * time.Minute).Milliseconds() {
+		t.Errorf("Expected task b to take 30m, got %s", humanDuration(got))
+	}
+	if c := stats.Tasks[2]; !c.InProgress || c.DurationMs != (5*time.Minute).Milliseconds() {
+		t.Errorf("Expected the current task to be 5m in progress, got %+v", c)
+	}
+	if first := stats.Chapters[0]; first.Completed != 2 || first.Attempts != 6 || first.Hints != 2 || first.DurationMs != (40*time.Minute).Milliseconds() {
+		t.Errorf("Unexpected chapter totals: %+v", first)
+	}
+	if len(stats.Slowest) != 2 || stats.Slowest[0].ID != "b" {
+		t.Errorf("Expected b to be the slowest finished task, got %+v", stats.Slowest)
+	}
+	if stats.Remaining != 2 || stats.AverageMs != (20*time.Minute).Milliseconds() || stats.ETAMs != (40*time.Minute).Milliseconds() {
+		t.Errorf("Expected a 40m ETA at 20m per task, got %+v", stats)
+	}
+}
+
+func TestTrackCheck(t *testing.T) {
+	state := &types.State{}
+	trackCheck(state, "a", false)
+	trackCheck(state, "a", true)
+	firstPass := state.TaskStats["a"].FirstPassAt
+	trackCheck(state, "a", true)
+
+	stats := state.TaskStats["a"]
+	if stats.Attempts != 3 || stats.FirstFailAt.IsZero() || !stats.FirstPassAt.Equal(firstPass) {
+		t.Errorf("Expected three attempts keeping the first pass, got %+v", stats)
+	}
+}
+
+func TestRunStatsJSON(t *testing.T) {
+	writeRegressionQuest(t)
+	RunCheck(testCommand(t, "check"), nil)
+
+	report, err := captureReport(t, testCommand(t, "stats"), RunStats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Stats == nil || len(report.Stats.Tasks) == 0 {
+		t.Fatalf("Expected stats in the report, got %+v", report)
+	}
+	for _, task := range report.Stats.Tasks {
+		if task.ID == "handlers" && (task.Attempts != 1 || task.FirstFailAt == nil) {
+			t.Errorf("Expected the failed check to be counted, got %+v", task)
+		}
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                              "-",
+		45 * time.Second:               "45s",
+		12*time.Minute + 5*time.Second: "12m",
+		2*time.Hour + 5*time.Minute:    "2h05m",
+		76 * time.Hour:                 "3d04h",
+	}
+	for d, want := range tests {
+		if got := humanDuration(d.Milliseconds()); got != want {
+			t.Errorf("humanDuration(%s) = %s, want %s", d, got, want)
+		}
+	}
+}
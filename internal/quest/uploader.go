@@ -2,7 +2,6 @@ package quest
 
 import (
 	"encoding/json"
-	"os"
 
 	"github.com/jovanpet/quest/internal/format"
 	"github.com/jovanpet/quest/internal/types"
@@ -14,7 +13,11 @@ func UploadState(state *types.State) error {
 		format.Error("Error marshaling state", err)
 		return err
 	}
-	err = os.WriteFile(StateFilePath, jsonState, 0644)
+	if err := backupOlderSchema(StateFilePath); err != nil {
+		format.ErrorWithTip("Error backing up the state file", err, "Check folder permissions")
+		return err
+	}
+	err = writeFileAtomic(StateFilePath, jsonState, 0644)
 	if err != nil {
 		format.ErrorWithTip("Error writing state file", err, "Check folder permissions")
 		return err
@@ -28,7 +31,11 @@ func UploadPlan(plan *types.Plan) error {
 		format.Error("Error marshaling plan", err)
 		return err
 	}
-	err = os.WriteFile(PlanFilePath, jsonPlan, 0644)
+	if err := backupOlderSchema(PlanFilePath); err != nil {
+		format.ErrorWithTip("Error backing up the plan file", err, "Check folder permissions")
+		return err
+	}
+	err = writeFileAtomic(PlanFilePath, jsonPlan, 0644)
 	if err != nil {
 		format.ErrorWithTip("Error writing plan file", err, "Check folder permissions")
 		return err
@@ -0,0 +1,289 @@
+package quest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/jovanpet/quest/internal/format"
+	"github.com/jovanpet/quest/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// RunWatch re-checks the current task whenever the files it depends on
+// change, until interrupted
+func RunWatch(cmd *cobra.Command, args []string) error {
+	interval, _ := cmd.Flags().GetDuration("interval")
+	debounce, _ := cmd.Flags().GetDuration("debounce")
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := &watcher{cmd: cmd, taskIndex: -1}
+	if err := w.reload(); err != nil {
+		return stopWatching(err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Checks run on this goroutine only, so they can never overlap. Files
+	// changed during a check differ from the snapshot taken before it and
+	// trigger another run once they settle.
+	pending := true
+	var changedAt time.Time
+	for {
+		if pending && time.Since(changedAt) >= debounce {
+			pending = false
+			w.files = watchSnapshot(w.targets)
+			if err := w.check(ctx); err != nil {
+				return stopWatching(err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return stopWatching(errCancelled)
+		case <-ticker.C:
+		}
+
+		if w.taskChanged() {
+			if err := w.reload(); err != nil {
+				return stopWatching(err)
+			}
+			pending = true
+			continue
+		}
+		if files := watchSnapshot(w.targets); !sameInputs(files, w.files) {
+			w.files = files
+			changedAt = time.Now()
+			pending = true
+		}
+	}
+}
+
+// errNothingToWatch stops the watch once the quest has no task left
+var errNothingToWatch = errors.New("no task left to watch")
+
+// stopWatching turns the reason the watch ended into the command's result.
+// Ctrl-C and finishing the quest are normal ways to stop.
+func stopWatching(err error) error {
+	switch {
+	case errors.Is(err, errCancelled):
+		format.Newline()
+		format.Info("Stopped watching")
+		return nil
+	case errors.Is(err, errNothingToWatch):
+		return nil
+	}
+	return err
+}
+
+// watcher holds what quest watch knows about the task it is checking
+type watcher struct {
+	cmd       *cobra.Command
+	taskIndex int
+	targets   watchTargets
+	files     map[string]string
+	lastPass  *bool
+}
+
+// reload picks up the current task, which may have moved if quest next or
+// jumpTo ran in another terminal
+func (w *watcher) reload() error {
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
+		return commandError(err)
+	}
+	tasks := FlattenTasks(plan)
+	if state.CurrentTaskIndex >= len(tasks) {
+		format.Info("No task left to watch, the quest is complete")
+		return errNothingToWatch
+	}
+
+	task := tasks[state.CurrentTaskIndex]
+	w.taskIndex = state.CurrentTaskIndex
+	w.targets = taskWatchTargets(task)
+	w.lastPass = nil
+	return nil
+}
+
+// taskChanged reports whether the current task moved since the last reload
+func (w *watcher) taskChanged() bool {
+	state, err := LoadState()
+	return err == nil && state.CurrentTaskIndex != w.taskIndex
+}
+
+// check redraws the screen with a fresh check of the current task and
+// records the result. It returns an error once the watch should stop.
+func (w *watcher) check(ctx context.Context) error {
+	unlock, err := lockForUpdate()
+	if err != nil {
+		return commandError(err)
+	}
+	defer unlock()
+
+	state, plan, err := LoadStateAndPlan()
+	if err != nil {
+		format.ErrorWithTip("Failed to load quest data", err, "Run 'quest begin' to start a new quest")
+		return commandError(err)
+	}
+	if state.CurrentTaskIndex != w.taskIndex {
+		if err := w.reload(); err != nil {
+			return err
+		}
+		w.files = watchSnapshot(w.targets)
+	}
+	task := FlattenTasks(plan)[w.taskIndex]
+
+	format.ClearScreen()
+	format.CheckHeader(w.taskIndex+1, task.Title)
+	format.Dim(fmt.Sprintf("Watching %s · last run %s · Ctrl-C to stop", w.targets, time.Now().Format("15:04:05")))
+	format.Newline()
+
+	opts := checkOptions(w.cmd, state)
+	defer opts.Workspace.Close()
+	holdLockForCheck(w.cmd, []types.Task{task}, opts)
+	checkCtx := ctx
+	if timeout, _ := w.cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		checkCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	passedCount, failedCount, results := checkTask(checkCtx, &task, opts, true)
+	duration := time.Since(started)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return errCancelled
+	}
+	if err := opts.Cache.Save(); err != nil {
+		format.Warning(fmt.Sprintf("Could not save the check cache: %v", err))
+	}
+
+	passed := failedCount == 0
+	recordCheck(state, w.taskIndex, task, passed)
+	if passed {
+		format.CheckSummaryPass(passedCount)
+
+		if w.lastPass != nil && !*w.lastPass {
+			format.Bell()
+			format.Success("Task complete!")
+		}
+		format.CommandHint("Ready for next task? Run", "quest next")
+	} else {
+		format.CheckSummaryFail(passedCount, failedCount)
+		format.Dim("Save a file to check again")
+	}
+	w.lastPass = &passed
+
+	if err := UploadStateAndPlan(state, plan); err != nil {
+		format.ErrorWithTip("Failed to save quest data", err, "Check folder permissions")
+		return exitWith(ExitInternal, err)
+	}
+	logEvent(checkEvent(SourceWatch, w.taskIndex, task, state.LastCheck.Status, duration, results))
+	return nil
+}
+
+// watchTargets are the files and globs a task's check depends on. Rules that
+// build or run the learner's code depend on the whole workspace.
+type watchTargets struct {
+	Patterns  []string
+	Workspace bool
+}
+
+func (t watchTargets) String() string {
+	if t.Workspace {
+		return "the whole workspace"
+	}
+	return fmt.Sprintf("%d path(s)", len(t.Patterns))
+}
+
+// taskWatchTargets collects the task's artifacts and the paths and globs its
+// rules read
+func taskWatchTargets(task types.Task) watchTargets {
+	var targets watchTargets
+	add := func(pattern string) {
+		if pattern != "" && !contains(targets.Patterns, pattern) {
+			targets.Patterns = append(targets.Patterns, pattern)
+		}
+	}
+
+	for _, artifact := range task.Artifacts {
+		add(artifact)
+	}
+	gitWatched := false
+	for _, rule := range task.Validation.Rules {
+		switch rule.Type {
+		case types.TypeExists, types.TypeConfigFile:
+			add(rule.Path)
+		case types.TypeGlobCountMin, types.TypeFileContainsAny:
+			add(rule.Glob)
+		case types.TypeFunctionCases, types.TypeCommand, types.TypeHTTP, types.TypeOpenAPI:
+			targets.Workspace = true
+		default:
+			if isGitRule(rule) && !gitWatched {
+				// Commits and staging change these, the working tree is
+				// covered by the other targets. The workspace may be a
+				// folder below the top of the repository, so git says
+				// where they are.
+				gitWatched = true
+				if gitDir, err := runGit("rev-parse", "--absolute-git-dir"); err == nil {
+					add(filepath.Join(gitDir, "HEAD"))
+					add(filepath.Join(gitDir, "index"))
+				}
+			}
+		}
+	}
+	return targets
+}
+
+// watchSnapshot records the size and modification time of every watched
+// file, which is cheap enough to poll
+func watchSnapshot(targets watchTargets) map[string]string {
+	files := map[string]string{}
+	record := func(path string, info os.FileInfo) {
+		files[path] = fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+	}
+
+	for _, pattern := range targets.Patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil {
+				record(match, info)
+			}
+		}
+	}
+
+	if targets.Workspace {
+		filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				switch info.Name() {
+				case QuestFolderName, ".git", "node_modules":
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Mode().IsRegular() {
+				record(path, info)
+			}
+			return nil
+		})
+	}
+	return files
+}
@@ -0,0 +1,93 @@
+package quest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jovanpet/quest/internal/types"
+)
+
+func TestTaskWatchTargets(t *testing.T) {
+	task := types.Task{
+		Artifacts: []string{"main.go"},
+		Validation: types.Validation{Rules: []types.Rule{
+			{Type: types.TypeExists, Path: "main.go"},
+			{Type: types.TypeGlobCountMin, Glob: "*_test.go"},
+		}},
+	}
+	targets := taskWatchTargets(task)
+	if len(targets.Patterns) != 2 || targets.Workspace {
+		t.Errorf("Expected main.go and *_test.go only, got %+v", targets)
+	}
+
+	task.Validation.Rules = append(task.Validation.Rules, types.Rule{Type: types.TypeCommand, Command: "go"})
+	if !taskWatchTargets(task).Workspace {
+		t.Error("Expected a command rule to watch the whole workspace")
+	}
+}
+
+func TestTaskWatchTargetsGitInSubfolder(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, _ := filepath.EvalSymlinks(t.TempDir())
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	gitInTest(t, "init", "-q")
+	service := filepath.Join(dir, "services", "api")
+	os.MkdirAll(service, 0755)
+	os.Chdir(service)
+
+	// The workspace is below the top of the repository
+	task := types.Task{Validation: types.Validation{Rules: []types.Rule{{Type: types.TypeGitClean}}}}
+	targets := taskWatchTargets(task)
+	for _, want := range []string{filepath.Join(dir, ".git", "HEAD"), filepath.Join(dir, ".git", "index")} {
+		if !contains(targets.Patterns, want) {
+			t.Errorf("Expected %s to be watched, got %v", want, targets.Patterns)
+		}
+	}
+}
+
+func TestWatchSnapshotDetectsChanges(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	targets := watchTargets{Patterns: []string{"*.go"}}
+	os.WriteFile("main.go", []byte("package main\n"), 0644)
+	before := watchSnapshot(targets)
+
+	os.WriteFile("notes.txt", []byte("not watched"), 0644)
+	if !sameInputs(before, watchSnapshot(targets)) {
+		t.Error("Expected an unwatched file not to count as a change")
+	}
+
+	os.WriteFile("handlers.go", []byte("package main\n"), 0644)
+	if sameInputs(before, watchSnapshot(targets)) {
+		t.Error("Expected a new matching file to count as a change")
+	}
+
+	before = watchSnapshot(targets)
+	later := time.Now().Add(time.Second)
+	os.Chtimes("main.go", later, later)
+	if sameInputs(before, watchSnapshot(targets)) {
+		t.Error("Expected a modified file to count as a change")
+	}
+
+	os.MkdirAll(QuestDir, 0755)
+	workspace := watchTargets{Workspace: true}
+	before = watchSnapshot(workspace)
+	os.WriteFile(StateFilePath, []byte("{}"), 0644)
+	if !sameInputs(before, watchSnapshot(workspace)) {
+		t.Error("Expected quest's own files to be ignored")
+	}
+}
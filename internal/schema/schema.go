@@ -0,0 +1,300 @@
+// Package schema validates decoded JSON values against the subset of JSON
+// Schema that templates and OpenAPI documents commonly use: type, enum,
+// const, properties, required, additionalProperties, items, string and
+// number bounds, pattern, allOf/anyOf/oneOf, nullable and local $refs.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Validate checks value against schema and returns one message per violation
+func Validate(value, schema interface{}) []string {
+	return ValidateIn(value, schema, schema)
+}
+
+// ValidateIn is Validate with local "#/..." $refs resolved against root
+func ValidateIn(value, schema, root interface{}) []string {
+	v := &validator{root: root}
+	v.validate("$", value, schema, 0)
+	return v.errors
+}
+
+// Parse decodes a raw JSON schema
+func Parse(raw json.RawMessage) (interface{}, error) {
+	var schema interface{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return schema, nil
+}
+
+// Resolve follows a local "#/a/b" reference inside root
+func Resolve(root interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local $ref values are supported, got '%s'", ref)
+	}
+	current := root
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot resolve $ref '%s'", ref)
+		}
+		if current, ok = obj[part]; !ok {
+			return nil, fmt.Errorf("cannot resolve $ref '%s'", ref)
+		}
+	}
+	return current, nil
+}
+
+// maxDepth guards against self-referencing schemas
+const maxDepth = 64
+
+type validator struct {
+	root   interface{}
+	errors []string
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) validate(path string, value, schema interface{}, depth int) {
+	if depth > maxDepth {
+		v.fail(path, "schema nesting is too deep")
+		return
+	}
+
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		// true/absent schemas accept anything, false rejects everything
+		if b, isBool := schema.(bool); isBool && !b {
+			v.fail(path, "no value is allowed here")
+		}
+		return
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		resolved, err := Resolve(v.root, ref)
+		if err != nil {
+			v.fail(path, "%s", err.Error())
+			return
+		}
+		v.validate(path, value, resolved, depth+1)
+		return
+	}
+
+	if value == nil {
+		if nullable, _ := s["nullable"].(bool); nullable {
+			return
+		}
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(value, t) {
+		v.fail(path, "expected %s, got %s", describeType(t), typeName(value))
+		return
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, option := range enum {
+			if reflect.DeepEqual(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "%s is not one of %s", compact(value), compact(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		v.fail(path, "expected %s, got %s", compact(c), compact(value))
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(path, val, s, depth)
+	case []interface{}:
+		v.validateArray(path, val, s, depth)
+	case string:
+		v.validateString(path, val, s)
+	case float64:
+		v.validateNumber(path, val, s)
+	}
+
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			v.validate(path, value, sub, depth+1)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok && v.countMatches(value, anyOf, depth) == 0 {
+		v.fail(path, "does not match any of the allowed schemas")
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		if n := v.countMatches(value, oneOf, depth); n != 1 {
+			v.fail(path, "matches %d of the oneOf schemas, expected exactly 1", n)
+		}
+	}
+}
+
+func (v *validator) countMatches(value interface{}, schemas []interface{}, depth int) int {
+	n := 0
+	for _, sub := range schemas {
+		probe := &validator{root: v.root}
+		probe.validate("$", value, sub, depth+1)
+		if len(probe.errors) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (v *validator) validateObject(path string, obj map[string]interface{}, s map[string]interface{}, depth int) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, present := obj[name]; !present {
+				v.fail(path, "missing required property '%s'", name)
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "." + key
+		if propSchema, ok := properties[key]; ok {
+			v.validate(childPath, obj[key], propSchema, depth+1)
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(path, "unexpected property '%s'", key)
+			}
+		case map[string]interface{}:
+			v.validate(childPath, obj[key], additional, depth+1)
+		}
+	}
+}
+
+func (v *validator) validateArray(path string, list []interface{}, s map[string]interface{}, depth int) {
+	if n, ok := number(s["minItems"]); ok && float64(len(list)) < n {
+		v.fail(path, "expected at least %v item(s), got %d", n, len(list))
+	}
+	if n, ok := number(s["maxItems"]); ok && float64(len(list)) > n {
+		v.fail(path, "expected at most %v item(s), got %d", n, len(list))
+	}
+	if items, ok := s["items"]; ok {
+		for i, item := range list {
+			v.validate(path+"["+strconv.Itoa(i)+"]", item, items, depth+1)
+		}
+	}
+}
+
+func (v *validator) validateString(path, str string, s map[string]interface{}) {
+	length := float64(len([]rune(str)))
+	if n, ok := number(s["minLength"]); ok && length < n {
+		v.fail(path, "expected at least %v character(s)", n)
+	}
+	if n, ok := number(s["maxLength"]); ok && length > n {
+		v.fail(path, "expected at most %v character(s)", n)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			v.fail(path, "invalid pattern %q in schema", pattern)
+		} else if !re.MatchString(str) {
+			v.fail(path, "%q does not match pattern %q", str, pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(path string, n float64, s map[string]interface{}) {
+	if min, ok := number(s["minimum"]); ok && n < min {
+		v.fail(path, "%v is less than the minimum %v", n, min)
+	}
+	if max, ok := number(s["maximum"]); ok && n > max {
+		v.fail(path, "%v is greater than the maximum %v", n, max)
+	}
+}
+
+func matchesType(value interface{}, t interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesTypeName(value, tt)
+	case []interface{}:
+		for _, option := range tt {
+			if name, ok := option.(string); ok && matchesTypeName(value, name) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTypeName(value interface{}, name string) bool {
+	actual := typeName(value)
+	return actual == name || (name == "number" && actual == "integer")
+}
+
+func typeName(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == float64(int64(val)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func describeType(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		names := make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v interface{}) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
+
+func compact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
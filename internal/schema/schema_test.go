@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", s, err)
+	}
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	schemaDoc := `{
+		"type": "object",
+		"required": ["id", "title"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"title": {"type": "string", "minLength": 1},
+			"status": {"enum": ["open", "done"]},
+			"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}},
+			"due": {"type": "string", "nullable": true}
+		}
+	}`
+
+	tests := []struct {
+		name   string
+		value  string
+		errors []string
+	}{
+		{name: "valid", value: `{"id": 1, "title": "x", "status": "open", "tags": ["go"], "due": null}`},
+		{name: "missing required", value: `{"id": 1}`, errors: []string{"$: missing required property 'title'"}},
+		{name: "wrong type", value: `{"id": "1", "title": "x"}`, errors: []string{"$.id: expected integer, got string"}},
+		{name: "integer bound", value: `{"id": 0, "title": "x"}`, errors: []string{"$.id: 0 is less than the minimum 1"}},
+		{name: "enum", value: `{"id": 1, "title": "x", "status": "later"}`, errors: []string{`$.status: "later" is not one of ["open","done"]`}},
+		{name: "array items", value: `{"id": 1, "title": "x", "tags": ["ok", "NO"]}`, errors: []string{`$.tags[1]: "NO" does not match pattern "^[a-z]+$"`}},
+		{name: "additional", value: `{"id": 1, "title": "x", "extra": 1}`, errors: []string{"$: unexpected property 'extra'"}},
+	}
+
+	s := decode(t, schemaDoc)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Validate(decode(t, tt.value), s)
+			if strings.Join(got, "\n") != strings.Join(tt.errors, "\n") {
+				t.Errorf("Validate() = %q, expected %q", got, tt.errors)
+			}
+		})
+	}
+}
+
+func TestValidateInResolvesRefs(t *testing.T) {
+	root := decode(t, `{"components": {"schemas": {"Todo": {"type": "object", "required": ["id"]}}}}`)
+	ref := decode(t, `{"type": "array", "items": {"$ref": "#/components/schemas/Todo"}}`)
+
+	if errs := ValidateIn(decode(t, `[{"id": 1}]`), ref, root); len(errs) != 0 {
+		t.Errorf("Expected no errors, got %v", errs)
+	}
+	if errs := ValidateIn(decode(t, `[{}]`), ref, root); len(errs) != 1 {
+		t.Errorf("Expected 1 error, got %v", errs)
+	}
+	if errs := ValidateIn(decode(t, `{}`), decode(t, `{"$ref": "#/missing"}`), root); len(errs) != 1 {
+		t.Errorf("Expected unresolved $ref error, got %v", errs)
+	}
+}
@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Plan struct {
 	Version       int       `json:"version"`
@@ -43,25 +46,93 @@ type Validation struct {
 }
 
 type Rule struct {
-	Type        Type   `json:"type"` // "exists", "glob_count_min", "file_contains_any"
+	Type        Type   `json:"type"` // "exists", "glob_count_min", "file_contains_any", "function_cases", "config_file", "openapi", "command", "http", "git_commits", "git_clean", "git_branch", "git_message"
 	Name        string `json:"name,omitempty"`
 	Description string `json:"description,omitempty"`
+	Timeout     string `json:"timeout,omitempty"` // e.g. "30s", defaults to two minutes
 
-	// For Type == "exists"
+	// For Type == "exists" and "config_file", and the package directory for "function_cases"
 	Path string `json:"path,omitempty"`
 
 	// For Type == "glob_count_min" and "file_contains_any"
 	Glob string `json:"glob,omitempty"`
 
-	// For Type == "glob_count_min"
+	// For Type == "glob_count_min" and "git_commits"
 	Min int `json:"min,omitempty"`
 
 	// For Type == "file_contains_any"
 	Any []string `json:"any,omitempty"`
 
+	// For Type == "function_cases"
+	Function string     `json:"function,omitempty"`
+	Cases    []TestCase `json:"cases,omitempty"`
+
+	// For Type == "config_file"
+	Format string          `json:"format,omitempty"` // "json", "yaml", "toml", "env"; detected from the extension when empty
+	Keys   []KeyAssertion  `json:"keys,omitempty"`
+	Schema json.RawMessage `json:"schema,omitempty"` // JSON Schema the whole file must satisfy
+
+	// For Type == "openapi"
+	Spec     string          `json:"spec,omitempty"`     // path to an OpenAPI document in the workspace
+	Document json.RawMessage `json:"document,omitempty"` // inline OpenAPI document shipped with the template
+
+	// For Type == "openapi" and "http" the command starts the learner's server
+	// (defaults to "go run ."), for Type == "command" it is the program to run
+	Command string   `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`    // extra arguments for "command", passed verbatim
+	BaseURL string   `json:"baseUrl,omitempty"` // where the server listens, defaults to the document's servers or http://localhost:8080
+
+	// For Type == "command"
+	Stdin    string `json:"stdin,omitempty"`
+	ExitCode int    `json:"exitCode,omitempty"`
+
+	// For Type == "http"
+	Method string `json:"method,omitempty"` // defaults to GET
+	URL    string `json:"url,omitempty"`    // path appended to the base URL
+	Body   string `json:"body,omitempty"`
+	Status int    `json:"status,omitempty"` // expected status code, defaults to 200
+
+	// Golden output for "command" and "http". Golden names a file under
+	// .quest/goldens/; GoldenText is the template-shipped fallback.
+	Golden     string        `json:"golden,omitempty"`
+	GoldenText string        `json:"goldenText,omitempty"`
+	Normalize  []string      `json:"normalize,omitempty"` // "timestamps", "uuids", "ports", "durations"
+	Replace    []Replacement `json:"replace,omitempty"`
+
+	// For Type == "git_branch" and "git_message", a regex the branch name or
+	// each commit subject must match. "git_commits" uses Min.
+	Pattern string `json:"pattern,omitempty"`
+
 	LastState *CheckState `json:"lastState,omitempty"`
 }
 
+// TestCase is one input/output case for a "function_cases" rule.
+// Args and Expected are JSON values decoded into the function's parameter
+// and result types.
+type TestCase struct {
+	Name          string            `json:"name,omitempty"`
+	Args          []json.RawMessage `json:"args"`
+	Expected      json.RawMessage   `json:"expected,omitempty"`
+	ExpectedError string            `json:"expectedError,omitempty"` // substring the returned error must contain
+	WantError     bool              `json:"wantError,omitempty"`
+}
+
+// KeyAssertion checks one key path (e.g. "services.web.ports[0]") of a
+// parsed config file. Only the fields that are set are checked.
+type KeyAssertion struct {
+	Key     string          `json:"key"`
+	Type    string          `json:"type,omitempty"` // "string", "number", "integer", "boolean", "object", "array", "null"
+	Equals  json.RawMessage `json:"equals,omitempty"`
+	Matches string          `json:"matches,omitempty"` // regex the value must match
+}
+
+// Replacement rewrites every match of Pattern (a regex) to With before
+// output is compared with its golden file.
+type Replacement struct {
+	Pattern string `json:"pattern"`
+	With    string `json:"with"`
+}
+
 type State struct {
 	Version int `json:"version"`
 
@@ -71,6 +142,9 @@ type State struct {
 	// Task IDs that have been completed
 	CompletedTaskIDs []string `json:"completedTaskIds"`
 
+	// Task IDs that passed once but failed the last 'quest check --all'
+	RegressedTaskIDs []string `json:"regressedTaskIds,omitempty"`
+
 	// Result of the most recent check (nil if never run)
 	LastCheck *CheckResult `json:"lastCheck"`
 
@@ -78,6 +152,31 @@ type State struct {
 
 	// Track how many times explain was called for current task
 	ExplainCount int `json:"explainCount"`
+
+	// When the current task started and the HEAD commit at that moment
+	// (empty outside a git repository or before the first commit)
+	TaskStartedAt   time.Time `json:"taskStartedAt,omitempty"`
+	TaskStartCommit string    `json:"taskStartCommit,omitempty"`
+
+	// How the learner got through each task, by task ID
+	TaskStats map[string]*TaskStats `json:"taskStats,omitempty"`
+
+	// Snapshot every file of the module instead of the task's files only
+	SnapshotModule bool `json:"snapshotModule,omitempty"`
+
+	// Folder of the quest's code relative to the folder holding .quest, empty
+	// when it is that folder itself
+	Workspace string `json:"workspace,omitempty"`
+}
+
+// TaskStats tracks the time and effort a task took. The times stay at the
+// first occurrence, later checks only add to Attempts.
+type TaskStats struct {
+	ShownAt     time.Time `json:"shownAt,omitempty"`     // first shown by 'quest next'
+	FirstFailAt time.Time `json:"firstFailAt,omitempty"` // first failing check
+	FirstPassAt time.Time `json:"firstPassAt,omitempty"` // first passing check
+	Attempts    int       `json:"attempts"`              // checks run on the task
+	Hints       int       `json:"hints"`                 // times 'quest explain' was asked
 }
 
 type CheckResult struct {
@@ -95,6 +194,9 @@ const (
 	CheckPass CheckStatus = "pass"
 	CheckWarn CheckStatus = "warn"
 	CheckFail CheckStatus = "fail"
+	// CheckUpdated is check --update-goldens rewriting goldens, which says
+	// nothing about whether the output is right
+	CheckUpdated CheckStatus = "updated"
 )
 
 type Type string
@@ -103,6 +205,15 @@ const (
 	TypeExists          Type = "exists"
 	TypeGlobCountMin    Type = "glob_count_min"
 	TypeFileContainsAny Type = "file_contains_any"
+	TypeFunctionCases   Type = "function_cases"
+	TypeConfigFile      Type = "config_file"
+	TypeOpenAPI         Type = "openapi"
+	TypeCommand         Type = "command"
+	TypeHTTP            Type = "http"
+	TypeGitCommits      Type = "git_commits"
+	TypeGitClean        Type = "git_clean"
+	TypeGitBranch       Type = "git_branch"
+	TypeGitMessage      Type = "git_message"
 )
 
 type CheckState string